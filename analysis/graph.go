@@ -0,0 +1,216 @@
+package analysis
+
+import (
+	"errors"
+)
+
+var (
+	// ErrTooLarge means the graph exceeds the nodes limit
+	ErrTooLarge = errors.New("analysis: graph is too large")
+	// ErrUnbounded means the query can not be answered by the coverability graph of an unbounded net
+	ErrUnbounded = errors.New("analysis: net is unbounded")
+)
+
+// Graph is a reachability graph of the net. When the net is unbounded the graph is a Karp-Miller coverability graph,
+// and markings contain Omega
+type Graph struct {
+	Net   *Net
+	Nodes []*Node
+
+	bounded bool
+	nn      map[string]int
+}
+
+// Node is a marking in the graph
+type Node struct {
+	Marking Marking
+	Edges   []Edge
+
+	v      []int
+	parent int
+	via    string
+}
+
+// Edge is a transition firing from one marking to another
+type Edge struct {
+	T  string
+	To int
+}
+
+// Reachability builds a reachability graph from the initial marking. When a marking strictly covering one of its
+// predecessors is found, the net is unbounded, so the Karp-Miller acceleration is applied and the graph becomes a
+// coverability graph. The coverability graph of a net with inhibitor or reset edges is an approximation. Limit
+// restricts a number of nodes, zero means no limit
+func (n *Net) Reachability(m0 Marking, limit int) (*Graph, error) {
+	v0, err := n.vector(m0)
+	if err != nil {
+		return nil, err
+	}
+	var g = &Graph{
+		Net:     n,
+		bounded: true,
+		nn:      map[string]int{},
+	}
+	g.add(v0, -1, "")
+	for i := 0; i < len(g.Nodes); i += 1 {
+		for t := range n.Transitions {
+			if !n.fireable(t, g.Nodes[i].v) {
+				continue
+			}
+			v := g.accelerate(i, n.fire(t, g.Nodes[i].v))
+			j, ok := g.nn[key(v)]
+			if !ok {
+				if limit > 0 && len(g.Nodes) >= limit {
+					return nil, ErrTooLarge
+				}
+				j = g.add(v, i, n.Transitions[t])
+			}
+			g.Nodes[i].Edges = append(g.Nodes[i].Edges, Edge{n.Transitions[t], j})
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) add(v []int, parent int, via string) int {
+	var i = len(g.Nodes)
+	g.Nodes = append(g.Nodes, &Node{
+		Marking: g.Net.marking(v),
+		v:       v,
+		parent:  parent,
+		via:     via,
+	})
+	g.nn[key(v)] = i
+	return i
+}
+
+// accelerate replaces by Omega all places where the marking strictly covers a predecessor on the path from the
+// initial marking
+func (g *Graph) accelerate(i int, v []int) []int {
+	for ; i >= 0; i = g.Nodes[i].parent {
+		a := g.Nodes[i].v
+		if !covers(v, a) || equal(v, a) {
+			continue
+		}
+		for k := range v {
+			if v[k] != Omega && v[k] > a[k] {
+				v[k] = Omega
+				g.bounded = false
+			}
+		}
+	}
+	return v
+}
+
+// Bounded returns true when numbers of tokens in all places are bounded
+func (g *Graph) Bounded() bool {
+	return g.bounded
+}
+
+// Bound returns the maximum number of tokens in the place, or Omega when the place is unbounded
+func (g *Graph) Bound(p string) int {
+	var b int
+	for _, nd := range g.Nodes {
+		c := nd.Marking[p]
+		if c == Omega {
+			return Omega
+		}
+		if c > b {
+			b = c
+		}
+	}
+	return b
+}
+
+// Deadlocks returns firing sequences to markings where no transition is enabled
+func (g *Graph) Deadlocks() [][]string {
+	var ss [][]string
+	for i, nd := range g.Nodes {
+		if len(nd.Edges) == 0 {
+			ss = append(ss, g.sequence(i))
+		}
+	}
+	return ss
+}
+
+// DeadlockFree returns true when at least one transition is enabled in each reachable marking
+func (g *Graph) DeadlockFree() bool {
+	return len(g.Deadlocks()) == 0
+}
+
+// Reachable returns a firing sequence to the target marking. The query is answered for bounded nets only
+func (g *Graph) Reachable(target Marking) ([]string, bool, error) {
+	if !g.bounded {
+		return nil, false, ErrUnbounded
+	}
+	v, err := g.Net.vector(target)
+	if err != nil {
+		return nil, false, err
+	}
+	i, ok := g.nn[key(v)]
+	if !ok {
+		return nil, false, nil
+	}
+	return g.sequence(i), true, nil
+}
+
+// Coverable returns a firing sequence to a marking which covers the target marking
+func (g *Graph) Coverable(target Marking) ([]string, bool, error) {
+	v, err := g.Net.vector(target)
+	if err != nil {
+		return nil, false, err
+	}
+	for i, nd := range g.Nodes {
+		if covers(nd.v, v) {
+			return g.sequence(i), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// completing returns nodes from which the target marking is reachable
+func (g *Graph) completing(target Marking) []bool {
+	var r = make([]bool, len(g.Nodes))
+	v, err := g.Net.vector(target)
+	if err != nil {
+		return r
+	}
+	i, ok := g.nn[key(v)]
+	if !ok {
+		return r
+	}
+
+	var back = make([][]int, len(g.Nodes))
+	for k, nd := range g.Nodes {
+		for _, e := range nd.Edges {
+			back[e.To] = append(back[e.To], k)
+		}
+	}
+	r[i] = true
+	for queue := []int{i}; len(queue) > 0; queue = queue[1:] {
+		for _, k := range back[queue[0]] {
+			if !r[k] {
+				r[k] = true
+				queue = append(queue, k)
+			}
+		}
+	}
+	return r
+}
+
+func (nd *Node) bounded() bool {
+	for _, c := range nd.v {
+		if c == Omega {
+			return false
+		}
+	}
+	return true
+}
+
+// sequence returns transition names fired from the initial marking to the node
+func (g *Graph) sequence(i int) []string {
+	var ss []string
+	for ; g.Nodes[i].parent >= 0; i = g.Nodes[i].parent {
+		ss = append([]string{g.Nodes[i].via}, ss...)
+	}
+	return ss
+}
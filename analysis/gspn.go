@@ -0,0 +1,360 @@
+package analysis
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/alxmsl/cpn"
+)
+
+// DefaultFirings is a number of simulated firings when it is not set by WithFirings
+const DefaultFirings = 10000
+
+// Distribution samples a firing delay of a timed transition
+type Distribution func(r *rand.Rand) time.Duration
+
+// Exponential creates an exponential distribution with the mean delay
+func Exponential(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// Deterministic creates a distribution which always returns the delay
+func Deterministic(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Uniform creates a uniform distribution of delays in [min, max]. Max should not be less than min
+func Uniform(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return min + time.Duration(r.Int63n(int64(max-min)+1))
+	}
+}
+
+// SimulationOption is an abstraction to define options of the stochastic simulation
+type SimulationOption interface {
+	Apply(*simulation)
+}
+
+// WithImmediate creates an option to set a weight of the immediate transition. Transitions are immediate with weight 1
+// by default. When several immediate transitions are enabled, one of them fires with a probability proportional to
+// its weight
+func WithImmediate(t string, weight float64) SimulationOption {
+	return immediateOpt{t, weight}
+}
+
+type immediateOpt struct {
+	t      string
+	weight float64
+}
+
+func (o immediateOpt) Apply(s *simulation) {
+	s.weights[o.t] = o.weight
+}
+
+// WithTimed creates an option to make the transition timed. Timed transition fires after a delay sampled from the
+// distribution when it becomes enabled. The delay is discarded when the transition is disabled before firing
+func WithTimed(t string, d Distribution) SimulationOption {
+	return timedOpt{t, d}
+}
+
+type timedOpt struct {
+	t string
+	d Distribution
+}
+
+func (o timedOpt) Apply(s *simulation) {
+	s.distributions[o.t] = o.d
+}
+
+// WithFirings creates an option to set a number of simulated firings
+func WithFirings(n int) SimulationOption {
+	return firingsOpt{n}
+}
+
+type firingsOpt struct {
+	n int
+}
+
+func (o firingsOpt) Apply(s *simulation) {
+	s.firings = o.n
+}
+
+// WithSeed creates an option to set a seed of the random source. Simulations with the same seed are reproducible
+func WithSeed(seed int64) SimulationOption {
+	return seedOpt{seed}
+}
+
+type seedOpt struct {
+	seed int64
+}
+
+func (o seedOpt) Apply(s *simulation) {
+	s.seed = o.seed
+}
+
+// Statistics is a result of the stochastic simulation
+type Statistics struct {
+	// Time is a simulated time
+	Time time.Duration
+	// Firings keeps numbers of firings by transition names
+	Firings map[string]int
+	// Throughput keeps numbers of firings per second of the simulated time by transition names
+	Throughput map[string]float64
+	// Tokens keeps time-averaged numbers of tokens by place names
+	Tokens map[string]float64
+	// Sojourn keeps mean times tokens spend in places by place names. Places without departed tokens are omitted
+	Sojourn map[string]time.Duration
+	// Deadlock means the simulation stopped before the number of firings, because no transition is enabled
+	Deadlock bool
+}
+
+type simulation struct {
+	weights       map[string]float64
+	distributions map[string]Distribution
+	firings       int
+	seed          int64
+}
+
+// token is a simulated token. Its history has the same form as M.History
+type token struct {
+	path []*cpn.E
+}
+
+// Simulate runs the net as a generalized stochastic Petri net from the initial marking. Immediate transitions fire
+// before timed ones without advancing the virtual clock. Timed transitions race: the one with the earliest sampled
+// firing time fires, and the clock advances to that time. Priorities, read, reset and inhibitor edges are respected
+func (n *Net) Simulate(m0 Marking, opts ...SimulationOption) (*Statistics, error) {
+	var s = &simulation{
+		weights:       map[string]float64{},
+		distributions: map[string]Distribution{},
+		firings:       DefaultFirings,
+		seed:          1,
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	for k, w := range s.weights {
+		if _, ok := n.tt[k]; !ok {
+			return nil, fmt.Errorf("analysis: unknown transition %q", k)
+		}
+		if w <= 0 {
+			return nil, fmt.Errorf("analysis: transition %q: non-positive weight %v", k, w)
+		}
+		if _, ok := s.distributions[k]; ok {
+			return nil, fmt.Errorf("analysis: transition %q: both immediate and timed", k)
+		}
+	}
+	for k, d := range s.distributions {
+		if _, ok := n.tt[k]; !ok {
+			return nil, fmt.Errorf("analysis: unknown transition %q", k)
+		}
+		if d == nil {
+			return nil, fmt.Errorf("analysis: transition %q: no distribution", k)
+		}
+	}
+	v, err := n.vector(m0)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		r     = rand.New(rand.NewSource(s.seed))
+		now   time.Duration
+		epoch time.Time
+		st    = &Statistics{
+			Firings:    map[string]int{},
+			Throughput: map[string]float64{},
+			Tokens:     map[string]float64{},
+		}
+		area = make([]float64, len(n.Places))
+		tt   = make([][]*token, len(n.Places))
+		done []*token
+		// scheduled keeps firing times of enabled timed transitions
+		scheduled = map[int]time.Duration{}
+	)
+	for _, name := range n.Transitions {
+		st.Firings[name] = 0
+	}
+	for i, c := range v {
+		if c == Omega {
+			return nil, fmt.Errorf("analysis: place %q: unbounded number of tokens", n.Places[i])
+		}
+		for k := 0; k < c; k += 1 {
+			tt[i] = append(tt[i], &token{path: []*cpn.E{{T: epoch, N: n.Places[i]}}})
+		}
+	}
+
+	for f := 0; f < s.firings; f += 1 {
+		var (
+			immediate []int
+			total     float64
+		)
+		for t, name := range n.Transitions {
+			if _, ok := s.distributions[name]; ok || !n.fireable(t, v) {
+				continue
+			}
+			immediate = append(immediate, t)
+			total += s.weight(name)
+		}
+
+		var t = -1
+		if len(immediate) > 0 {
+			x := r.Float64() * total
+			for _, u := range immediate {
+				t = u
+				if x -= s.weight(n.Transitions[u]); x < 0 {
+					break
+				}
+			}
+		} else {
+			for u, name := range n.Transitions {
+				d, ok := s.distributions[name]
+				if !ok || !n.fireable(u, v) {
+					continue
+				}
+				if _, ok := scheduled[u]; !ok {
+					scheduled[u] = now + d(r)
+				}
+				if t < 0 || scheduled[u] < scheduled[t] {
+					t = u
+				}
+			}
+			if t < 0 {
+				st.Deadlock = true
+				break
+			}
+			for i, c := range v {
+				area[i] += float64(c) * float64(scheduled[t]-now)
+			}
+			now = scheduled[t]
+			delete(scheduled, t)
+		}
+
+		done = append(done, n.move(t, tt, epoch.Add(now))...)
+		v = n.fire(t, v)
+		st.Firings[n.Transitions[t]] += 1
+		for u := range scheduled {
+			if !n.fireable(u, v) {
+				delete(scheduled, u)
+			}
+		}
+	}
+
+	st.Time = now
+	for _, name := range n.Transitions {
+		if now > 0 {
+			st.Throughput[name] = float64(st.Firings[name]) / now.Seconds()
+		}
+	}
+	for i, name := range n.Places {
+		if now > 0 {
+			st.Tokens[name] = area[i] / float64(now)
+		}
+		done = append(done, tt[i]...)
+	}
+	var hh = make([][]*cpn.E, 0, len(done))
+	for _, tk := range done {
+		hh = append(hh, tk.path)
+	}
+	st.Sojourn = n.sojourn(hh)
+	return st, nil
+}
+
+func (s *simulation) weight(t string) float64 {
+	if w, ok := s.weights[t]; ok {
+		return w
+	}
+	return 1
+}
+
+// move moves simulated tokens when the transition fires at the time. Consumed tokens are taken from places in the
+// FIFO order. The first produced token continues the history of the first consumed token, other produced tokens start
+// new histories. It returns tokens which leave the net
+func (n *Net) move(t int, tt [][]*token, at time.Time) []*token {
+	var (
+		consumed []*token
+		done     []*token
+		next     *token
+		e        = &cpn.E{T: at, N: n.Transitions[t]}
+	)
+	for i, c := range n.Pre[t] {
+		consumed = append(consumed, tt[i][:c]...)
+		tt[i] = tt[i][c:]
+	}
+	for i, reset := range n.Resets[t] {
+		if !reset {
+			continue
+		}
+		for _, tk := range tt[i] {
+			tk.path = append(tk.path, e)
+			if d := n.discards[t][i]; d >= 0 {
+				tk.path = append(tk.path, &cpn.E{T: at, N: n.Places[d]})
+				tt[d] = append(tt[d], tk)
+				continue
+			}
+			done = append(done, tk)
+		}
+		tt[i] = nil
+	}
+	for _, tk := range consumed {
+		tk.path = append(tk.path, e)
+	}
+	if len(consumed) > 0 {
+		next, consumed = consumed[0], consumed[1:]
+	}
+	for i, c := range n.Post[t] {
+		for k := 0; k < c; k += 1 {
+			var tk = next
+			if tk != nil {
+				next = nil
+			} else {
+				tk = &token{path: []*cpn.E{e}}
+			}
+			tk.path = append(tk.path, &cpn.E{T: at, N: n.Places[i]})
+			tt[i] = append(tt[i], tk)
+		}
+	}
+	if next != nil {
+		done = append(done, next)
+	}
+	return append(done, consumed...)
+}
+
+// Sojourn returns mean times tokens spend in places by place names. Time in a place is measured from the place edge to
+// the next edge of the token history. Places without departed tokens are omitted
+func (n *Net) Sojourn(mm []*cpn.M) map[string]time.Duration {
+	var hh = make([][]*cpn.E, 0, len(mm))
+	for _, m := range mm {
+		hh = append(hh, m.History())
+	}
+	return n.sojourn(hh)
+}
+
+func (n *Net) sojourn(hh [][]*cpn.E) map[string]time.Duration {
+	var (
+		total = make([]time.Duration, len(n.Places))
+		count = make([]int, len(n.Places))
+		r     = map[string]time.Duration{}
+	)
+	for _, h := range hh {
+		for k := 0; k+1 < len(h); k += 1 {
+			i, ok := n.pp[h[k].N]
+			if !ok {
+				continue
+			}
+			total[i] += h[k+1].T.Sub(h[k].T)
+			count[i] += 1
+		}
+	}
+	for i, name := range n.Places {
+		if count[i] > 0 {
+			r[name] = total[i] / time.Duration(count[i])
+		}
+	}
+	return r
+}
@@ -0,0 +1,149 @@
+package analysis
+
+// Invariant keeps non-zero weights by place or transition names.
+//
+// Place invariant is a conservation law: the weighted sum of tokens is the same in every reachable marking. Transition
+// invariant is a multiset of transitions which firing reproduces the marking
+type Invariant map[string]int
+
+// Value returns the weighted sum of tokens in the marking. It is used to check place invariants
+func (inv Invariant) Value(m Marking) int {
+	var s int
+	for k, w := range inv {
+		s += w * m[k]
+	}
+	return s
+}
+
+// PInvariants returns minimal semi-positive place invariants of the net
+func (n *Net) PInvariants() []Invariant {
+	return invariants(n.Incidence(), n.Places)
+}
+
+// TInvariants returns minimal semi-positive transition invariants of the net
+func (n *Net) TInvariants() []Invariant {
+	var c = n.Incidence()
+	var ct = make([][]int, len(n.Transitions))
+	for j := range n.Transitions {
+		ct[j] = make([]int, len(n.Places))
+		for i := range n.Places {
+			ct[j][i] = c[i][j]
+		}
+	}
+	return invariants(ct, n.Transitions)
+}
+
+// invariants computes minimal semi-positive solutions of y*A = 0 by the Farkas algorithm. Rows of the matrix are
+// indexed by names
+func invariants(a [][]int, names []string) []Invariant {
+	var (
+		rows = len(names)
+		cols int
+	)
+	if rows > 0 {
+		cols = len(a[0])
+	}
+
+	// Each row is the matrix row followed by the identity row
+	var dd = make([][]int, rows)
+	for i := range dd {
+		dd[i] = make([]int, cols+rows)
+		copy(dd[i], a[i])
+		dd[i][cols+i] = 1
+	}
+
+	for j := 0; j < cols; j += 1 {
+		var next [][]int
+		for _, d := range dd {
+			if d[j] == 0 {
+				next = append(next, d)
+			}
+		}
+		for _, p := range dd {
+			if p[j] <= 0 {
+				continue
+			}
+			for _, q := range dd {
+				if q[j] >= 0 {
+					continue
+				}
+				r := make([]int, len(p))
+				for k := range r {
+					r[k] = -q[j]*p[k] + p[j]*q[k]
+				}
+				next = append(next, normalize(r))
+			}
+		}
+		dd = minimal(next, cols)
+	}
+
+	var ii []Invariant
+	for _, d := range dd {
+		inv := Invariant{}
+		for i, w := range d[cols:] {
+			if w != 0 {
+				inv[names[i]] = w
+			}
+		}
+		ii = append(ii, inv)
+	}
+	return ii
+}
+
+// normalize divides the row by the greatest common divisor of its values
+func normalize(r []int) []int {
+	var g int
+	for _, v := range r {
+		g = gcd(g, v)
+	}
+	if g > 1 {
+		for k := range r {
+			r[k] /= g
+		}
+	}
+	return r
+}
+
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// minimal removes rows which identity part support is a superset of another row support, and duplicated rows
+func minimal(dd [][]int, cols int) [][]int {
+	var r [][]int
+	for i, d := range dd {
+		keep := true
+		for k, e := range dd {
+			if i == k {
+				continue
+			}
+			if subset(e[cols:], d[cols:]) && (!subset(d[cols:], e[cols:]) || k < i) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			r = append(r, d)
+		}
+	}
+	return r
+}
+
+// subset returns true when the support of a is a subset of the support of b
+func subset(a, b []int) bool {
+	for k := range a {
+		if a[k] != 0 && b[k] == 0 {
+			return false
+		}
+	}
+	return true
+}
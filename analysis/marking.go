@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Omega means an unbounded number of tokens in a place of the coverability graph
+const Omega = -1
+
+// Marking keeps numbers of tokens by place names. Places without tokens are omitted
+type Marking map[string]int
+
+func (m Marking) String() string {
+	var kk = make([]string, 0, len(m))
+	for k := range m {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+
+	var ss = make([]string, 0, len(kk))
+	for _, k := range kk {
+		if m[k] == Omega {
+			ss = append(ss, fmt.Sprintf("%s:ω", k))
+			continue
+		}
+		ss = append(ss, fmt.Sprintf("%s:%d", k, m[k]))
+	}
+	return "{" + strings.Join(ss, " ") + "}"
+}
+
+func key(v []int) string {
+	return fmt.Sprint(v)
+}
+
+func equal(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// covers returns true when the marking a is greater or equal to the marking b in each place
+func covers(a, b []int) bool {
+	for i := range a {
+		if a[i] == Omega {
+			continue
+		}
+		if b[i] == Omega || a[i] < b[i] {
+			return false
+		}
+	}
+	return true
+}
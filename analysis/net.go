@@ -0,0 +1,202 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/alxmsl/cpn"
+)
+
+// Net is a place/transition structure of the PN. Places and transitions are sorted by name, so their indexes are
+// stable for the same PN
+type Net struct {
+	Places      []string
+	Transitions []string
+
+	// Pre and Post keep numbers of tokens are consumed and produced by transitions. They are indexed by transition,
+	// then by place
+	Pre  [][]int
+	Post [][]int
+	// Reads keeps weights of read edges. Transition is enabled only when the place holds at least as many tokens as the
+	// weight, but the tokens are not consumed. It is indexed by transition, then by place
+	Reads [][]int
+	// Priorities keeps priorities of transitions. Transition doesn't fire while a transition with a higher priority,
+	// which consumes tokens from the same place, is enabled
+	Priorities []int
+	// Resets keeps reset edges. Transition removes all tokens from the place when it fires. Removed tokens are moved to
+	// the discard place, if any. It is indexed by transition, then by place
+	Resets [][]bool
+	// Inhibitors keeps weights of inhibitor edges. Transition is enabled only when the place holds less tokens than the
+	// weight. Zero means there is no inhibitor edge. It is indexed by transition, then by place
+	Inhibitors [][]int
+
+	pp map[string]int
+	tt map[string]int
+	// discards keeps indexes of discard places of reset edges, or -1 when removed tokens are not kept. It is indexed by
+	// transition, then by place
+	discards [][]int
+}
+
+// NewNet derives a place/transition structure from the PN
+func NewNet(pn *cpn.PN) *Net {
+	var n = &Net{
+		pp: map[string]int{},
+		tt: map[string]int{},
+	}
+	for i, p := range pn.Places() {
+		n.Places = append(n.Places, p.Name())
+		n.pp[p.Name()] = i
+	}
+	for i, t := range pn.Transitions() {
+		n.Transitions = append(n.Transitions, t.Name())
+		n.Priorities = append(n.Priorities, t.Priority())
+		n.tt[t.Name()] = i
+
+		pre, post := make([]int, len(n.Places)), make([]int, len(n.Places))
+		for _, p := range t.Ins() {
+			pre[n.pp[p]] += t.InWeight(p)
+		}
+		for _, p := range t.Outs() {
+			post[n.pp[p]] += t.OutWeight(p)
+		}
+		rd, inh := make([]int, len(n.Places)), make([]int, len(n.Places))
+		for _, p := range t.Reads() {
+			rd[n.pp[p]] = t.ReadWeight(p)
+		}
+		for _, p := range t.Inhibitors() {
+			inh[n.pp[p]] = t.InhibitorWeight(p)
+		}
+		n.Pre = append(n.Pre, pre)
+		n.Post = append(n.Post, post)
+		rs, ds := make([]bool, len(n.Places)), make([]int, len(n.Places))
+		for i := range ds {
+			ds[i] = -1
+		}
+		for _, p := range t.Resets() {
+			rs[n.pp[p]] = true
+			if d := t.ResetDiscard(p); d != "" {
+				ds[n.pp[p]] = n.pp[d]
+			}
+		}
+		n.Reads = append(n.Reads, rd)
+		n.Resets = append(n.Resets, rs)
+		n.discards = append(n.discards, ds)
+		n.Inhibitors = append(n.Inhibitors, inh)
+	}
+	return n
+}
+
+// Incidence returns the incidence matrix of the net. Matrix is indexed by place, then by transition. Value is a change
+// of tokens number in the place when the transition fires
+func (n *Net) Incidence() [][]int {
+	var c = make([][]int, len(n.Places))
+	for i := range n.Places {
+		c[i] = make([]int, len(n.Transitions))
+		for j := range n.Transitions {
+			c[i][j] = n.Post[j][i] - n.Pre[j][i]
+		}
+	}
+	return c
+}
+
+// vector converts the marking to a vector indexed by place
+func (n *Net) vector(m Marking) ([]int, error) {
+	var v = make([]int, len(n.Places))
+	for k, c := range m {
+		i, ok := n.pp[k]
+		if !ok {
+			return nil, fmt.Errorf("analysis: unknown place %q", k)
+		}
+		if c < 0 && c != Omega {
+			return nil, fmt.Errorf("analysis: place %q: negative number of tokens %d", k, c)
+		}
+		v[i] = c
+	}
+	return v, nil
+}
+
+// marking converts the vector indexed by place to a marking
+func (n *Net) marking(v []int) Marking {
+	var m = Marking{}
+	for i, c := range v {
+		if c != 0 {
+			m[n.Places[i]] = c
+		}
+	}
+	return m
+}
+
+// enabled returns true when the transition is enabled in the marking. Unbounded place always inhibits the transition
+func (n *Net) enabled(t int, v []int) bool {
+	for i, c := range n.Pre[t] {
+		if v[i] != Omega && v[i] < c {
+			return false
+		}
+	}
+	for i, c := range n.Reads[t] {
+		if v[i] != Omega && v[i] < c {
+			return false
+		}
+	}
+	for i, c := range n.Inhibitors[t] {
+		if c > 0 && (v[i] == Omega || v[i] >= c) {
+			return false
+		}
+	}
+	return true
+}
+
+// fireable returns true when the transition is enabled in the marking, and there is no enabled conflicting transition
+// with a higher priority
+func (n *Net) fireable(t int, v []int) bool {
+	if !n.enabled(t, v) {
+		return false
+	}
+	for u := range n.Transitions {
+		if n.Priorities[u] > n.Priorities[t] && n.conflict(t, u) && n.enabled(u, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// conflict returns true when both transitions consume tokens from the same place
+func (n *Net) conflict(t, u int) bool {
+	for i := range n.Places {
+		if n.Pre[t][i] > 0 && n.Pre[u][i] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fire returns a new marking after the transition fires in the marking. The transition should be enabled. Tokens are
+// consumed first, then reset places are emptied, then tokens are produced
+func (n *Net) fire(t int, v []int) []int {
+	var r = make([]int, len(v))
+	for i := range v {
+		if v[i] == Omega {
+			r[i] = Omega
+			continue
+		}
+		r[i] = v[i] - n.Pre[t][i]
+	}
+	for i, reset := range n.Resets[t] {
+		if !reset {
+			continue
+		}
+		if d := n.discards[t][i]; d >= 0 && r[d] != Omega {
+			if r[i] == Omega {
+				r[d] = Omega
+			} else {
+				r[d] += r[i]
+			}
+		}
+		r[i] = 0
+	}
+	for i := range r {
+		if r[i] != Omega {
+			r[i] += n.Post[t][i]
+		}
+	}
+	return r
+}
@@ -0,0 +1,245 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alxmsl/cpn"
+)
+
+var (
+	// ErrNotWorkflow means the net is not a workflow net
+	ErrNotWorkflow = errors.New("analysis: not a workflow net")
+	// ErrNoOptionToComplete means the terminal marking is not reachable from some reachable marking
+	ErrNoOptionToComplete = errors.New("analysis: no option to complete")
+	// ErrImproperCompletion means a token reaches the terminal place while other tokens are left in the net
+	ErrImproperCompletion = errors.New("analysis: improper completion")
+	// ErrDeadTransition means the transition never fires
+	ErrDeadTransition = errors.New("analysis: dead transition")
+)
+
+// Violation describes a soundness problem. Sequence is a firing sequence from the initial marking which leads to the
+// problem
+type Violation struct {
+	Err        error
+	Place      string
+	Transition string
+	Sequence   []string
+	Reason     string
+}
+
+func (v *Violation) Error() string {
+	var s = v.Err.Error()
+	if v.Place != "" {
+		s += fmt.Sprintf(" place %q", v.Place)
+	}
+	if v.Transition != "" {
+		s += fmt.Sprintf(" %q", v.Transition)
+	}
+	if v.Reason != "" {
+		s += ": " + v.Reason
+	}
+	if v.Sequence != nil {
+		s += fmt.Sprintf(" after [%s]", strings.Join(v.Sequence, " "))
+	}
+	return s
+}
+
+func (v *Violation) Unwrap() error {
+	return v.Err
+}
+
+// SoundnessError contains all soundness violations of the workflow net
+type SoundnessError struct {
+	Violations []*Violation
+}
+
+func (e *SoundnessError) Error() string {
+	var ss = make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		ss = append(ss, v.Error())
+	}
+	return strings.Join(ss, "; ")
+}
+
+// CheckWorkflowSoundness checks the net is a sound workflow net. Workflow net has one initial place, one terminal
+// place, and every place and transition is on a path between them. Workflow net is sound when from each marking
+// reachable from one token in the initial place it is possible to reach one token in the terminal place, the terminal
+// place gets a token when all other places are empty, and each transition may fire. Error, timeout and discard places
+// without outgoing edges are not a part of the workflow, so they are skipped
+func CheckWorkflowSoundness(pn *cpn.PN) error {
+	var (
+		n   = NewNet(pn)
+		aux = auxiliary(pn)
+		err = &SoundnessError{}
+	)
+	i, o, vv := n.workflow(pn, aux)
+	if len(vv) > 0 {
+		err.Violations = vv
+		return err
+	}
+	// Tokens removed by reset edges are not kept in skipped discard places, so they don't spoil the completion
+	for _, ds := range n.discards {
+		for k, d := range ds {
+			if d >= 0 && aux[n.Places[d]] {
+				ds[k] = -1
+			}
+		}
+	}
+
+	g, gerr := n.Reachability(Marking{i: 1}, 0)
+	if gerr != nil {
+		return gerr
+	}
+	if !g.Bounded() {
+		for k, nd := range g.Nodes {
+			if !nd.bounded() {
+				err.Violations = append(err.Violations, &Violation{
+					Err:      ErrUnbounded,
+					Sequence: g.sequence(k),
+					Reason:   fmt.Sprintf("marking %s", nd.Marking),
+				})
+				break
+			}
+		}
+		return err
+	}
+
+	for k, nd := range g.Nodes {
+		if nd.Marking[o] > 0 && (len(nd.Marking) > 1 || nd.Marking[o] > 1) {
+			err.Violations = append(err.Violations, &Violation{
+				Err:      ErrImproperCompletion,
+				Sequence: g.sequence(k),
+				Reason:   fmt.Sprintf("marking %s", nd.Marking),
+			})
+			break
+		}
+	}
+
+	var completing = g.completing(Marking{o: 1})
+	for k, nd := range g.Nodes {
+		if !completing[k] {
+			err.Violations = append(err.Violations, &Violation{
+				Err:      ErrNoOptionToComplete,
+				Sequence: g.sequence(k),
+				Reason:   fmt.Sprintf("marking %s", nd.Marking),
+			})
+			break
+		}
+	}
+
+	var fired = map[string]bool{}
+	for _, nd := range g.Nodes {
+		for _, e := range nd.Edges {
+			fired[e.T] = true
+		}
+	}
+	for _, t := range n.Transitions {
+		if !fired[t] {
+			err.Violations = append(err.Violations, &Violation{Err: ErrDeadTransition, Transition: t})
+		}
+	}
+
+	if len(err.Violations) > 0 {
+		return err
+	}
+	return nil
+}
+
+// auxiliary returns error, timeout and discard places without outgoing edges, unless transitions also produce tokens
+// to them by regular edges
+func auxiliary(pn *cpn.PN) map[string]bool {
+	var regular, aux = map[string]bool{}, map[string]bool{}
+	for _, t := range pn.Transitions() {
+		for _, p := range t.Outs() {
+			regular[p] = true
+		}
+	}
+	for _, t := range pn.Transitions() {
+		var pp = []string{t.Error(), t.Timeout()}
+		for _, p := range t.Resets() {
+			pp = append(pp, t.ResetDiscard(p))
+		}
+		for _, p := range pp {
+			if p != "" && !regular[p] && len(pn.P(p).Outs()) == 0 {
+				aux[p] = true
+			}
+		}
+	}
+	return aux
+}
+
+// workflow returns the initial and the terminal places of the workflow net. Auxiliary places are skipped. Violations
+// are returned when the net is not a workflow net
+func (n *Net) workflow(pn *cpn.PN, aux map[string]bool) (string, string, []*Violation) {
+	var ii, oo []string
+	for _, p := range pn.Places() {
+		if aux[p.Name()] {
+			continue
+		}
+		if p.Initial() {
+			ii = append(ii, p.Name())
+		}
+		if p.Terminal() {
+			oo = append(oo, p.Name())
+		}
+	}
+	if len(ii) != 1 || len(oo) != 1 {
+		return "", "", []*Violation{{
+			Err: ErrNotWorkflow,
+			Reason: fmt.Sprintf("initial places [%s], terminal places [%s]",
+				strings.Join(ii, " "), strings.Join(oo, " ")),
+		}}
+	}
+
+	var (
+		i, o   = ii[0], oo[0]
+		fwd    = visit(i, func(p *cpn.P) []string { return p.Outs() }, func(t *cpn.T) []string { return t.Outs() }, pn)
+		bwd    = visit(o, func(p *cpn.P) []string { return p.Ins() }, func(t *cpn.T) []string { return t.Ins() }, pn)
+		reason = fmt.Sprintf("not on a path from %q to %q", i, o)
+		vv     []*Violation
+	)
+	for _, p := range n.Places {
+		if aux[p] {
+			continue
+		}
+		if !fwd["p:"+p] || !bwd["p:"+p] {
+			vv = append(vv, &Violation{Err: ErrNotWorkflow, Place: p, Reason: reason})
+		}
+	}
+	for _, t := range n.Transitions {
+		if !fwd["t:"+t] || !bwd["t:"+t] {
+			vv = append(vv, &Violation{Err: ErrNotWorkflow, Transition: t, Reason: reason})
+		}
+	}
+	return i, o, vv
+}
+
+// visit returns all places and transitions which are reachable from the place by edges returned by functions. Keys
+// are prefixed by "p:" and "t:" for places and transitions
+func visit(p string, pf func(*cpn.P) []string, tf func(*cpn.T) []string, pn *cpn.PN) map[string]bool {
+	var (
+		seen = map[string]bool{"p:" + p: true}
+		pp   = []string{p}
+	)
+	for len(pp) > 0 {
+		var next []string
+		for _, p := range pp {
+			for _, t := range pf(pn.P(p)) {
+				if seen["t:"+t] {
+					continue
+				}
+				seen["t:"+t] = true
+				for _, q := range tf(pn.T(t)) {
+					if !seen["p:"+q] {
+						seen["p:"+q] = true
+						next = append(next, q)
+					}
+				}
+			}
+		}
+		pp = next
+	}
+	return seen
+}
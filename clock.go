@@ -0,0 +1,58 @@
+package cpn
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is an abstraction to define a source of time for the net. Token histories, transition delays and firing
+// timeouts are measured by the clock of the net. See PN.SetClock
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by the Clock. C receives the time when the timer expires
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// SystemClock is a clock which uses the wall time. Net uses it by default
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+type clockKey struct{}
+
+// ContextWithClock returns a copy of the context which carries the clock. Net passes its clock to strategies and
+// context transformations this way
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockFromContext returns the clock carried by the context, or SystemClock when there is no clock
+func ClockFromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return SystemClock{}
+}
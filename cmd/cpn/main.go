@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alxmsl/cpn"
+)
+
+const usage = `Usage: cpn <command> [flags] <file>
+
+Commands:
+  validate  checks the net structure
+  dot       renders the net in the Graphviz DOT format
+  stats     prints the net statistics
+  simulate  runs the net with in-memory strategies and prints token paths
+
+Net file is a JSON or YAML definition. YAML is detected by .yaml and .yml extensions
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var (
+		cmd     = os.Args[1]
+		fs      = flag.NewFlagSet(cmd, flag.ExitOnError)
+		tokens  = fs.Int("tokens", 1, "number of tokens written to each initial place (simulate)")
+		timeout = fs.Duration("timeout", time.Second, "time to wait for tokens drain (simulate)")
+	)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	switch cmd {
+	case "validate", "dot", "stats", "simulate":
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err := run(os.Stdout, cmd, fs.Arg(0), *tokens, *timeout); err != nil {
+		fail(err)
+	}
+}
+
+// run loads the net definition and executes the command. Simulation uses in-memory stand-ins for all strategies and
+// transformations, other commands use registered ones
+func run(w io.Writer, cmd, name string, tokens int, timeout time.Duration) error {
+	spec, err := decode(name)
+	if err != nil {
+		return err
+	}
+	var reg = registry()
+	if cmd == "simulate" {
+		reg = standins(spec)
+	}
+	n, err := cpn.Load(spec, reg)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "validate":
+		if err := n.Validate(); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, "ok")
+		return err
+	case "dot":
+		return n.WriteDOT(w)
+	case "stats":
+		return stats(w, n)
+	case "simulate":
+		return simulate(w, n, tokens, timeout)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// decode reads the net definition
+func decode(name string) (*cpn.Spec, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var spec *cpn.Spec
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		spec, err = cpn.DecodeYAML(f)
+	default:
+		spec, err = cpn.DecodeJSON(f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type CmdSuite struct {
+	dir string
+}
+
+var _ = Suite(&CmdSuite{})
+
+func (s *CmdSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *CmdSuite) file(c *C, name, content string) string {
+	var path = filepath.Join(s.dir, name)
+	c.Assert(ioutil.WriteFile(path, []byte(content), os.ModePerm), IsNil)
+	return path
+}
+
+const valid = `
+places:
+  - name: pin
+    strategy: memory.queue
+    options:
+      length: 10
+  - name: pout
+    strategy: memory.block
+transitions:
+  - name: t1
+    transformation: first
+arcs:
+  - from: pin
+    to: t1
+  - from: t1
+    to: pout
+`
+
+func (s *CmdSuite) TestValidate(c *C) {
+	w := bytes.NewBufferString("")
+	c.Assert(run(w, "validate", s.file(c, "net.yaml", valid), 1, time.Second), IsNil)
+	c.Assert(w.String(), Equals, "ok\n")
+}
+
+func (s *CmdSuite) TestSimulate(c *C) {
+	w := bytes.NewBufferString("")
+	c.Assert(run(w, "simulate", s.file(c, "net.yaml", valid), 1, time.Second), IsNil)
+	c.Assert(w.String(), Equals, "pout: pin:0\n  path: pin -> t1 -> pout\n  word: t1\n")
+}
+
+func (s *CmdSuite) TestUnknownStrategy(c *C) {
+	name := s.file(c, "net.json", `{"places": [{"name": "pin", "strategy": "memroy.queue"}]}`)
+	for _, cmd := range []string{"validate", "dot", "stats"} {
+		err := run(bytes.NewBufferString(""), cmd, name, 1, time.Second)
+		c.Assert(err, ErrorMatches, `spec: place "pin": unknown strategy "memroy.queue"`)
+	}
+}
+
+func (s *CmdSuite) TestUnknownOption(c *C) {
+	name := s.file(c, "net.json", `{"places": [{"name": "pin", "strategy": "memory.queue", "options": {"lenght": 10}}]}`)
+	err := run(bytes.NewBufferString(""), "validate", name, 1, time.Second)
+	c.Assert(err, ErrorMatches, `spec: place "pin": strategy "memory.queue": unknown option "lenght"`)
+}
+
+func (s *CmdSuite) TestUnknownTransformation(c *C) {
+	name := s.file(c, "net.json", `{"transitions": [{"name": "t1", "transformation": "frist"}]}`)
+	err := run(bytes.NewBufferString(""), "validate", name, 1, time.Second)
+	c.Assert(err, ErrorMatches, `spec: transition "t1": unknown transformation "frist"`)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alxmsl/cpn"
+)
+
+// simulate writes tokens to each initial place, then shuts the net down and prints paths and words of tokens reached
+// terminal places
+func simulate(w io.Writer, n *cpn.PN, tokens int, timeout time.Duration) error {
+	var (
+		initial  []*cpn.P
+		terminal []*cpn.P
+	)
+	for _, p := range n.Places() {
+		if p.Initial() {
+			initial = append(initial, p)
+		}
+		if p.Terminal() {
+			p.SetOptions(cpn.WithKeep(true))
+			terminal = append(terminal, p)
+		}
+	}
+	if err := n.Run(); err != nil {
+		return err
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	wg.Add(len(terminal))
+	for _, p := range terminal {
+		go func(p *cpn.P) {
+			defer wg.Done()
+			for m := range p.Out() {
+				mu.Lock()
+				fmt.Fprintf(w, "%s: %v\n", p.Name(), m.Value())
+				fmt.Fprintf(w, "  path: %s\n", path(m))
+				fmt.Fprintf(w, "  word: %s\n", strings.Join(m.Word(), " "))
+				mu.Unlock()
+			}
+		}(p)
+	}
+
+	for _, p := range initial {
+		for i := 0; i < tokens; i += 1 {
+			p.Send(cpn.NewM(fmt.Sprintf("%s:%d", p.Name(), i)))
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// Net is quiesced when tokens are stranded, so terminal places are drained as well
+	err := n.Shutdown(ctx)
+	if err == nil || errors.Is(err, cpn.ErrStrandedTokens) {
+		wg.Wait()
+	}
+	return err
+}
+
+func path(m *cpn.M) string {
+	var ss []string
+	for _, e := range m.Path() {
+		ss = append(ss, e.N)
+	}
+	return strings.Join(ss, " -> ")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/place/http"
+	"github.com/alxmsl/cpn/place/io"
+	"github.com/alxmsl/cpn/place/memory"
+	"github.com/alxmsl/cpn/place/redis"
+	"github.com/alxmsl/cpn/transition"
+)
+
+// registry creates a registry of all known strategies and transformations. Redis strategies are registered without a
+// pool, because the net is not run
+func registry() *cpn.Registry {
+	var r = cpn.NewRegistry()
+	http.Register(r)
+	io.Register(r)
+	memory.Register(r)
+	redis.Register(r, nil)
+	transition.Register(r)
+	return r
+}
+
+// standins creates a registry which maps every strategy used in the definition to the in-memory block, and every
+// transformation to the first token transformation. Strategy options are accepted and ignored
+func standins(spec *cpn.Spec) *cpn.Registry {
+	var r = cpn.NewRegistry()
+	for _, ps := range spec.Places {
+		r.AddStrategy(ps.Strategy, memory.NewBlock)
+		for k := range ps.Options {
+			r.AddStrategyOption(ps.Strategy, k, ignore)
+		}
+	}
+	for _, ts := range spec.Transitions {
+		r.AddTransformation(ts.Transformation, transition.First)
+	}
+	return r
+}
+
+func ignore(_ json.RawMessage) (cpn.StrategyOption, error) {
+	return noop{}, nil
+}
+
+type noop struct{}
+
+func (noop) Apply(cpn.Strategy) {}
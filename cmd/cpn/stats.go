@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alxmsl/cpn"
+)
+
+// stats prints a size of the net, initial and terminal places, fan-in and fan-out of each place and transition
+func stats(w io.Writer, n *cpn.PN) error {
+	var (
+		tw       = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		initial  []string
+		terminal []string
+	)
+	for _, p := range n.Places() {
+		if p.Initial() {
+			initial = append(initial, p.Name())
+		}
+		if p.Terminal() {
+			terminal = append(terminal, p.Name())
+		}
+	}
+
+	k, m := n.Size()
+	fmt.Fprintf(tw, "transitions:\t%d\n", k)
+	fmt.Fprintf(tw, "places:\t%d\n", m)
+	fmt.Fprintf(tw, "initial:\t%s\n", strings.Join(initial, ", "))
+	fmt.Fprintf(tw, "terminal:\t%s\n", strings.Join(terminal, ", "))
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "kind\tname\tfan-in\tfan-out")
+	for _, p := range n.Places() {
+		fmt.Fprintf(tw, "place\t%s\t%d\t%d\n", p.Name(), len(p.Ins()), len(p.Outs()))
+	}
+	for _, t := range n.Transitions() {
+		fmt.Fprintf(tw, "transition\t%s\t%d\t%d\n", t.Name(), len(t.Ins()), len(t.Outs()))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,99 @@
+// Package cpntest provides helpers for testing nets
+package cpntest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alxmsl/cpn"
+)
+
+// Clock is a manual clock. Its time moves only by Advance, so timing-dependent nets are tested without sleeping
+type Clock struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	now    time.Time
+	timers []*timer
+}
+
+// NewClock creates a manual clock which starts at the time
+func NewClock(now time.Time) *Clock {
+	var c = &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer which expires when the clock is advanced by the duration. Timer with non-positive duration
+// expires immediately
+func (c *Clock) NewTimer(d time.Duration) cpn.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var t = &timer{c: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance moves the clock forward by the duration. Expired timers receive their expiration times in the order of
+// expiration
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+	var k int
+	for k < len(c.timers) && !c.timers[k].at.After(c.now) {
+		c.timers[k].ch <- c.timers[k].at
+		k += 1
+	}
+	c.timers = append(c.timers[:0], c.timers[k:]...)
+	c.cond.Broadcast()
+}
+
+// BlockUntil waits until at least n timers are waiting for expiration. It is used to advance the clock after the net
+// starts waiting
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+type timer struct {
+	c  *Clock
+	at time.Time
+	ch chan time.Time
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop prevents the timer from expiration. It returns false when the timer is already expired or stopped
+func (t *timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	for i, u := range t.c.timers {
+		if u == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			t.c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
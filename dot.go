@@ -0,0 +1,143 @@
+package cpn
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// DOTOption is an abstraction to define options of the DOT rendering
+type DOTOption interface {
+	Apply(*dot)
+}
+
+// WithTokenCounts creates an option to annotate places and edges with live token counts. Places show a number of held
+// tokens, terminal places show a number of received tokens. Edges show a number of passed tokens
+func WithTokenCounts(counts bool) DOTOption {
+	return tokenCountsOpt{counts}
+}
+
+type tokenCountsOpt struct {
+	counts bool
+}
+
+func (o tokenCountsOpt) Apply(d *dot) {
+	d.counts = o.counts
+}
+
+type dot struct {
+	w   io.Writer
+	err error
+
+	counts bool
+}
+
+func (d *dot) printf(format string, aa ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, aa...)
+}
+
+// label renders the edge weight when it is greater than one, and a number of passed tokens in parentheses
+func (d *dot) label(a *arc) {
+	switch {
+	case d.counts && a.w > 1:
+		d.printf(" [label=\"%d (%d)\"]", a.w, atomic.LoadUint64(&a.n))
+	case d.counts:
+		d.printf(" [label=\"%d\"]", atomic.LoadUint64(&a.n))
+	case a.w > 1:
+		d.printf(" [label=\"%d\"]", a.w)
+	}
+}
+
+// WriteDOT renders the net in the Graphviz DOT format. Places are rendered as circles, transitions are rendered as
+// bars. Initial places are bold, terminal places have double border. Read edges have no arrow, reset edges have a
+// double arrow, inhibitor edges end with a circle. Removed tokens go to discard places by dashed edges, failed
+// tokens go to error places by red edges, timed out tokens go to timeout places by orange edges
+func (pn *PN) WriteDOT(w io.Writer, opts ...DOTOption) error {
+	var d = &dot{w: w}
+	for _, opt := range opts {
+		opt.Apply(d)
+	}
+
+	d.printf("digraph PN {\n")
+	d.printf("\trankdir=LR;\n")
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		p := v.(*P)
+		label := n
+		if d.counts {
+			p.mu.Lock()
+			label = fmt.Sprintf("%s\n%d", n, p.held())
+			p.mu.Unlock()
+		}
+		d.printf("\t%q [shape=circle, label=%q", "p:"+n, label)
+		if p.o&optionInitial > 0x0 {
+			d.printf(", style=bold")
+		}
+		if p.o&optionTerminal > 0x0 {
+			d.printf(", peripheries=2")
+		}
+		d.printf("];\n")
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		d.printf("\t%q [shape=box, style=filled, fillcolor=black, width=0.1, height=0.5, label=\"\", xlabel=%q];\n",
+			"t:"+n, n)
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		t := v.(*T)
+		t.ins.Over(func(_ int, np string, v interface{}) bool {
+			d.printf("\t%q -> %q", "p:"+np, "t:"+n)
+			d.label(v.(*arc))
+			d.printf(";\n")
+			return true
+		})
+		t.reads.Over(func(_ int, np string, v interface{}) bool {
+			d.printf("\t%q -> %q [dir=none", "p:"+np, "t:"+n)
+			if a := v.(*arc); a.w > 1 {
+				d.printf(", label=\"%d\"", a.w)
+			}
+			d.printf("];\n")
+			return true
+		})
+		t.resets.Over(func(_ int, np string, v interface{}) bool {
+			d.printf("\t%q -> %q [arrowhead=normalnormal];\n", "p:"+np, "t:"+n)
+			if a := v.(*arc); a.to != "" {
+				d.printf("\t%q -> %q [style=dashed];\n", "t:"+n, "p:"+a.to)
+			}
+			return true
+		})
+		t.inhibitors.Over(func(_ int, np string, v interface{}) bool {
+			d.printf("\t%q -> %q [arrowhead=odot", "p:"+np, "t:"+n)
+			if a := v.(*arc); a.w > 1 {
+				d.printf(", label=\"%d\"", a.w)
+			}
+			d.printf("];\n")
+			return true
+		})
+		t.outs.Over(func(_ int, np string, v interface{}) bool {
+			d.printf("\t%q -> %q", "t:"+n, "p:"+np)
+			d.label(v.(*arc))
+			d.printf(";\n")
+			return true
+		})
+		for _, e := range []struct {
+			a     *arc
+			color string
+		}{{t.errs, "red"}, {t.timeouts, "orange"}} {
+			if e.a == nil {
+				continue
+			}
+			d.printf("\t%q -> %q [color=%s", "t:"+n, "p:"+e.a.p.name, e.color)
+			if d.counts {
+				d.printf(", label=\"%d\"", atomic.LoadUint64(&e.a.n))
+			}
+			d.printf("];\n")
+		}
+		return true
+	})
+	d.printf("}\n")
+	return d.err
+}
@@ -0,0 +1,130 @@
+package cpn
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrFiringTimeout means a firing of a transition exceeds the timeout. See WithTimeout option
+	ErrFiringTimeout = errors.New("firing timeout")
+	// ErrInvalidConcurrency means a transition concurrency is less than one. See WithConcurrency option
+	ErrInvalidConcurrency = errors.New("invalid concurrency")
+	// ErrInvalidWeight means an edge weight is less than one
+	ErrInvalidWeight = errors.New("invalid weight")
+	// ErrNoContext means a place has no context. See WithContext option
+	ErrNoContext = errors.New("no context")
+	// ErrNoEnabledTransitions means no transition of the net may fire. See Stepper.Step
+	ErrNoEnabledTransitions = errors.New("no enabled transitions")
+	// ErrNoErrorPlace means a transition has a fallible transformation, but it has no error place, and the net has no
+	// error handler. See PN.TPError and PN.SetErrorHandler
+	ErrNoErrorPlace = errors.New("no error place")
+	// ErrNoInputs means a transition has no incoming places, so it never fires
+	ErrNoInputs = errors.New("no incoming places")
+	// ErrNoStrategy means a place has no strategy. See WithStrategy and WithStrategyBuilder options
+	ErrNoStrategy = errors.New("no strategy")
+	// ErrNoTransformation means a transition has neither transformation nor router. See WithTransformation,
+	// WithFallibleTransformation and WithRouter options
+	ErrNoTransformation = errors.New("no transformation")
+	// ErrNotEnabled means a policy chooses a transition which may not fire. See Stepper.Step
+	ErrNotEnabled = errors.New("transition is not enabled")
+	// ErrPlaceClosed means a token is put to the closed place. See Stepper.Close
+	ErrPlaceClosed = errors.New("place is closed")
+	// ErrScriptOver means a scripted policy has no transitions to choose. See ScriptPolicy
+	ErrScriptOver = errors.New("script is over")
+	// ErrStrandedTokens means the net is quiesced, but some tokens were not drained to terminal places. See PN.Shutdown
+	ErrStrandedTokens = errors.New("stranded tokens")
+)
+
+// PanicError describes a recovered panic. Stack is a stack trace of the goroutine which panicked. M is a token which
+// was processed, if any
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+	M     *M
+}
+
+// NewPanicError creates an error for the recovered value and attaches it to the token, if any. It should be called in
+// the deferred function, so the stack trace contains the panic location
+func NewPanicError(v interface{}, m *M) *PanicError {
+	var err = &PanicError{v, debug.Stack(), m}
+	if m != nil {
+		m.SetErr(err)
+	}
+	return err
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the recovered value when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ShutdownError describes places and transitions which were still busy when the shutdown context was done. When the
+// net is quiesced, it describes tokens which were not drained: Stranded keeps numbers of tokens left in places and
+// Dropped keeps numbers of consumed tokens for which transitions returned no token, failed tokens which had nowhere to
+// go, and tokens dropped by strategies of places. Both are keyed by names
+type ShutdownError struct {
+	Err         error
+	Places      []string
+	Transitions []string
+	Stranded    map[string]int
+	Dropped     map[string]int
+}
+
+func (e *ShutdownError) Error() string {
+	if e.Err == ErrStrandedTokens {
+		return fmt.Sprintf("shutdown: %v: left: [%s], dropped: [%s]", e.Err, join(e.Stranded), join(e.Dropped))
+	}
+	return fmt.Sprintf("shutdown: %v: busy places: [%s], busy transitions: [%s]",
+		e.Err, strings.Join(e.Places, ", "), strings.Join(e.Transitions, ", "))
+}
+
+// join renders numbers of tokens sorted by names
+func join(cc map[string]int) string {
+	var ss = make([]string, 0, len(cc))
+	for n, c := range cc {
+		ss = append(ss, fmt.Sprintf("%s:%d", n, c))
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, ", ")
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// StructureError describes a structural problem of the specific place or transition
+type StructureError struct {
+	Kind string
+	Name string
+	Err  error
+}
+
+func (e *StructureError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Kind, e.Name, e.Err)
+}
+
+func (e *StructureError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError contains all structural problems found in the net
+type ValidationError struct {
+	Errs []error
+}
+
+func (e *ValidationError) Error() string {
+	var ss = make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		ss = append(ss, err.Error())
+	}
+	return fmt.Sprintf("validation: %s", strings.Join(ss, "; "))
+}
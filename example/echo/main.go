@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log"
 	"os"
 
 	"github.com/alxmsl/cpn"
@@ -37,7 +38,9 @@ func main() {
 	n.
 		PT("req", "echo").
 		TP("echo", "log").
-		TP("echo", "res").
-		Run()
+		TP("echo", "res")
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
 	<-ctx.Done()
 }
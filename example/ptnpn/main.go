@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/alxmsl/cpn"
 	"github.com/alxmsl/cpn/place/memory"
@@ -35,12 +36,14 @@ func main() {
 	}()
 	for i := 0; i < places; i += 1 {
 		go func(i int) {
-			for m := range n.P(fmt.Sprintf("p_%d", i)).Out() {
+			for m := range n.P(fmt.Sprintf("p_:%d", i)).Out() {
 				fmt.Println(m)
 			}
 		}(i)
 	}
-	n.RunSync()
+	if err := n.RunSync(); err != nil {
+		log.Fatal(err)
+	}
 
 	k, m := n.Size()
 	fmt.Printf("pn size: %dx%d\n", k, m)
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/alxmsl/cpn"
@@ -28,8 +29,10 @@ func main() {
 	n.
 		PT("pin", "t").
 		TP("t", "pout1").
-		TP("t", "pout2").
-		Run()
+		TP("t", "pout2")
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("pin").In() <- cpn.NewM(i)
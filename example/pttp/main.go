@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/alxmsl/cpn"
 	"github.com/alxmsl/cpn/place/memory"
@@ -26,8 +27,10 @@ func main() {
 		PT("pin", "t1").
 		PT("pin", "t2").
 		TP("t1", "pout").
-		TP("t2", "pout").
-		Run()
+		TP("t2", "pout")
+	if err := n.Run(); err != nil {
+		log.Fatal(err)
+	}
 	go func() {
 		for i := 0; i < 10; i += 1 {
 			n.P("pin").In() <- cpn.NewM(i)
@@ -34,13 +34,17 @@ func init() {
 func main() {
 	fmt.Println("push to the queue:")
 	n1 := newPushPN()
-	n1.RunSync()
+	if err := n1.RunSync(); err != nil {
+		log.Fatal(err)
+	}
 
 	printQueueLength()
 
 	fmt.Println("pop from the queue:")
 	n2 := newPopPN()
-	n2.RunSync()
+	if err := n2.RunSync(); err != nil {
+		log.Fatal(err)
+	}
 }
 
 type MyType int
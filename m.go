@@ -1,12 +1,25 @@
 package cpn
 
 import (
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ids is a sequence of token identifiers
+var ids uint64
+
 // M is an abstraction to define a token in PN
 type M struct {
+	// id is a unique token identifier. parent is an identifier of the token which the token is cloned from, or zero
+	id     uint64
+	parent uint64
+	// branches keeps tokens which are joined to the token
+	branches []*M
+	// err is an error of the transformation or the strategy which failed on the token
+	err error
+
 	c time.Time
 	// v contains the current mark value
 	v interface{}
@@ -23,6 +36,9 @@ type M struct {
 type E struct {
 	T time.Time
 	N string
+
+	// t means the edge is a transition
+	t bool
 }
 
 // The v struct represents a mark's value written from the specific place
@@ -31,10 +47,16 @@ type v struct {
 	v interface{}
 }
 
+// NewM creates a token with the value. Token creation time is measured by SystemClock, see PN.NewM
 func NewM(value interface{}) *M {
+	return newM(SystemClock{}, value)
+}
+
+func newM(c Clock, value interface{}) *M {
 	return &M{
-		c: time.Now(),
-		v: value,
+		id: atomic.AddUint64(&ids, 1),
+		c:  c.Now(),
+		v:  value,
 
 		//@todo: set this value based on PN longest path size to reduce memory allocations
 		path: []*E{},
@@ -42,10 +64,123 @@ func NewM(value interface{}) *M {
 	}
 }
 
+// copy creates a new token with the same identifier, value and history
+func (m *M) copy() *M {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return &M{
+		id:       m.id,
+		parent:   m.parent,
+		branches: append([]*M{}, m.branches...),
+		err:      m.err,
+
+		c:    m.c,
+		v:    m.v,
+		vv:   append([]*v{}, m.vv...),
+		path: append([]*E{}, m.path...),
+		word: append([]string{}, m.word...),
+	}
+}
+
+// clone creates a new token with the same value and history. The token refers to the origin token as a parent
+func (m *M) clone() *M {
+	var c = m.copy()
+	c.id, c.parent = atomic.AddUint64(&ids, 1), m.id
+	return c
+}
+
+// Join creates a token which reunites branches of a forked token. The token has the value of the first branch and
+// the common parent of the branches, if any. Its history contains the common history of the branches, then edges
+// passed by all branches sorted by time. Values written by all branches are available by ValueByPlace
+func Join(mm ...*M) *M {
+	if len(mm) == 0 {
+		return nil
+	}
+	var j = mm[0].clone()
+	j.branches = append([]*M{}, mm...)
+	for _, m := range mm {
+		if m.Parent() != mm[0].Parent() {
+			j.parent = 0
+			break
+		}
+		j.parent = m.Parent()
+	}
+
+	var k = len(j.path)
+	for _, m := range mm[1:] {
+		m.lock.RLock()
+		n := prefix(j.path, m.path)
+		if n < k {
+			k = n
+		}
+		j.path = append(j.path, m.path[n:]...)
+		j.vv = append(j.vv, m.vv[prefixv(j.vv, m.vv):]...)
+		m.lock.RUnlock()
+	}
+	sort.SliceStable(j.path[k:], func(a, b int) bool {
+		return j.path[k+a].T.Before(j.path[k+b].T)
+	})
+	j.word = j.word[:0]
+	for _, e := range j.path {
+		if e.t {
+			j.word = append(j.word, e.N)
+		}
+	}
+	return j
+}
+
+// prefix returns a length of the common prefix of paths
+func prefix(a, b []*E) int {
+	var i int
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i += 1
+	}
+	return i
+}
+
+// prefixv returns a length of the common prefix of values
+func prefixv(a, b []*v) int {
+	var i int
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i += 1
+	}
+	return i
+}
+
+// ID returns a unique token identifier
+func (m *M) ID() uint64 {
+	return m.id
+}
+
+// Parent returns an identifier of the token which the token is cloned from. Transition clones a token when it passes
+// the token to several places. Zero means the token has no parent
+func (m *M) Parent() uint64 {
+	return m.parent
+}
+
+// Branches returns tokens which are joined to the token, see Join
+func (m *M) Branches() []*M {
+	return m.branches
+}
+
+// Err returns an error of the transformation or the strategy which failed on the token, or nil
+func (m *M) Err() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.err
+}
+
+// SetErr attaches the error of the failed transformation or strategy to the token
+func (m *M) SetErr(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.err = err
+}
+
 func (m *M) History() []*E {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	return append([]*E{{m.c, ""}}, m.path...)
+	return append([]*E{{T: m.c}}, m.path...)
 }
 
 // passP is called when the mark passed place in the net
@@ -56,7 +191,7 @@ func (m *M) passP(p *P) {
 		m.lock.Lock()
 		defer m.lock.Unlock()
 		if len(m.path) == 0 || m.path[len(m.path)-1].N != p.name {
-			m.path = append(m.path, &E{time.Now(), p.name})
+			m.path = append(m.path, &E{T: p.clock.Now(), N: p.name})
 			if m.v != nil {
 				m.vv = append(m.vv, &v{p, m.v})
 				m.v = nil
@@ -78,7 +213,7 @@ func (m *M) passP(p *P) {
 func (m *M) passT(t *T) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	m.path = append(m.path, &E{time.Now(), t.name})
+	m.path = append(m.path, &E{T: t.clock.Now(), N: t.name, t: true})
 	m.word = append(m.word, t.name)
 }
 
@@ -3,6 +3,7 @@ package cpn
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 const (
@@ -15,6 +16,9 @@ const (
 	optionLog uint64 = 1 << 2
 	// optionTerminal means a terminal place in the PN. Terminal place doesn't have outgoing edges
 	optionTerminal uint64 = 1 << 3
+	// optionEager means a place passes all tokens of its strategy to transitions without waiting for a room. Places
+	// with reset edges are eager, so transitions remove all their tokens at once
+	optionEager uint64 = 1 << 4
 )
 
 const (
@@ -22,8 +26,6 @@ const (
 	stateClosed uint64 = 1 << 0
 	// stateProcessing means place is processing a token
 	stateProcessing uint64 = 1 << 1
-	// stateReady means place is ready to pass token forward
-	stateReady uint64 = 1 << 2
 )
 
 type state struct {
@@ -143,3 +145,173 @@ type transformationOpt struct {
 func (o transformationOpt) Apply(t *T) {
 	t.transformation = o.transformation
 }
+
+// WithFallibleTransformation return a transition option to use specified transformation which may fail. Transition
+// should have an error place, see PN.TPError
+func WithFallibleTransformation(fn FallibleTransformation) TransitionOption {
+	return fallibleOpt{func(_ context.Context, mm []*M) (*M, error) {
+		return fn(mm)
+	}}
+}
+
+// WithContextTransformation return a transition option to use specified transformation which may fail or be
+// cancelled. Transition should have an error place, see PN.TPError
+func WithContextTransformation(fn ContextTransformation) TransitionOption {
+	return fallibleOpt{fn}
+}
+
+type fallibleOpt struct {
+	fallible ContextTransformation
+}
+
+func (o fallibleOpt) Apply(t *T) {
+	t.fallible = o.fallible
+}
+
+// WithRouter return a transition option to use specified router instead of a transformation
+func WithRouter(fn Router) TransitionOption {
+	return routerOpt{fn}
+}
+
+type routerOpt struct {
+	router Router
+}
+
+func (o routerOpt) Apply(t *T) {
+	t.router = o.router
+}
+
+// WithConcurrency returns a transition option to run up to n firings of the transition in parallel. Tokens are
+// consumed from incoming places one firing at a time, and transformations run concurrently
+func WithConcurrency(n int) TransitionOption {
+	return concurrencyOpt{n}
+}
+
+type concurrencyOpt struct {
+	n int
+}
+
+func (o concurrencyOpt) Apply(t *T) {
+	t.concurrency = o.n
+}
+
+// WithOrdered returns a transition option to pass tokens of concurrent firings to outgoing places in the order which
+// tokens are consumed in. See WithConcurrency
+func WithOrdered(ordered bool) TransitionOption {
+	return orderedOpt{ordered}
+}
+
+type orderedOpt struct {
+	ordered bool
+}
+
+func (o orderedOpt) Apply(t *T) {
+	t.ordered = o.ordered
+}
+
+// WithDelay returns a transition option to fire the transition only when it is enabled at least for the duration
+func WithDelay(d time.Duration) TransitionOption {
+	return delayOpt{d}
+}
+
+type delayOpt struct {
+	d time.Duration
+}
+
+func (o delayOpt) Apply(t *T) {
+	t.delay = o.d
+}
+
+// WithTimeout returns a transition option to limit a duration of each firing. When the firing exceeds the timeout,
+// the context of the transformation is cancelled, and consumed tokens are passed to the timeout place with
+// ErrFiringTimeout error. The transformation receives copies of consumed tokens, so it doesn't change timed out
+// tokens. Transformations without context are never cancelled: timed out call keeps running and counts against the
+// concurrency of the transition until it returns. See PN.TPTimeout and ContextTransformation
+func WithTimeout(d time.Duration) TransitionOption {
+	return timeoutOpt{d}
+}
+
+type timeoutOpt struct {
+	d time.Duration
+}
+
+func (o timeoutOpt) Apply(t *T) {
+	t.timeout = o.d
+}
+
+// WithGuard returns a transition option to fire the transition only when the guard accepts tokens. Guard receives
+// tokens grouped as for the transformation. It is called under places locks, so it should be fast and it must not
+// change tokens. When transitions compete for the same place, tokens go to the transition which guard accepts them.
+// Places pass all tokens to guarded transitions at once, and the first tokens accepted by the guard are consumed, so
+// a rejected token doesn't block tokens behind it
+func WithGuard(fn func([]*M) bool) TransitionOption {
+	return guardOpt{fn}
+}
+
+type guardOpt struct {
+	guard func([]*M) bool
+}
+
+func (o guardOpt) Apply(t *T) {
+	t.guard = o.guard
+}
+
+// WithPriority returns a transition option to set a priority. When several transitions are enabled on tokens of the
+// same place, the transition with the highest priority fires. Default priority is zero
+func WithPriority(priority int) TransitionOption {
+	return priorityOpt{priority}
+}
+
+type priorityOpt struct {
+	priority int
+}
+
+func (o priorityOpt) Apply(t *T) {
+	t.priority = o.priority
+}
+
+// ArcOption is an abstraction to define an edge option
+type ArcOption interface {
+	Apply(*arc)
+}
+
+// WithWeight creates an option to consume or produce several tokens through the edge
+func WithWeight(w int) ArcOption {
+	return weightOpt{w}
+}
+
+type weightOpt struct {
+	w int
+}
+
+func (o weightOpt) Apply(a *arc) {
+	a.w = o.w
+}
+
+// WithDiscard creates an option to pass tokens removed by a reset edge to the handler. Handler is called by the
+// transition goroutine
+func WithDiscard(fn func(*M)) ArcOption {
+	return discardOpt{fn}
+}
+
+type discardOpt struct {
+	discard func(*M)
+}
+
+func (o discardOpt) Apply(a *arc) {
+	a.discard = o.discard
+}
+
+// WithDiscardPlace creates an option to pass tokens removed by a reset edge to the place, so their history could be
+// inspected later
+func WithDiscardPlace(p string) ArcOption {
+	return discardPlaceOpt{p}
+}
+
+type discardPlaceOpt struct {
+	p string
+}
+
+func (o discardPlaceOpt) Apply(a *arc) {
+	a.to = o.p
+}
@@ -3,6 +3,7 @@ package cpn
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/alxmsl/cpn/trace"
 	"github.com/alxmsl/prmtvs/skm"
@@ -16,6 +17,12 @@ type Strategy interface {
 	Run(context.Context)
 }
 
+// Dropper is implemented by strategies which drop tokens, for example when a strategy function panics. Dropped tokens
+// are reported by PN.Shutdown
+type Dropper interface {
+	Dropped() int
+}
+
 // P implements an abstract place in PN
 type P struct {
 	ctx  context.Context
@@ -34,14 +41,52 @@ type P struct {
 
 	// ins is a sorted set of incoming edges
 	ins *skm.SKM
-	// out is a channel for outgoing edges
-	out chan *M
+	// outs is a sorted set of transitions which consume tokens from the place. They are notified when the place state
+	// is changed
+	outs *skm.SKM
+	// readers is a sorted set of transitions which read tokens from the place. They are notified when the place state
+	// is changed
+	readers *skm.SKM
+	// resetters is a sorted set of transitions which remove all tokens from the place
+	resetters *skm.SKM
+	// watchers is a sorted set of transitions which check the place because of conflicting transitions with a higher
+	// priority. They are notified when the place state is changed
+	watchers *skm.SKM
+	// inhibited is a sorted set of transitions which are inhibited by the place. They are notified when tokens are
+	// taken from the place, or the place never loses tokens again
+	inhibited *skm.SKM
 
 	// o keeps a static options flags for an abstract place. See options constants for details
 	o uint64
+	// clock measures times when tokens pass the place. It is set by the net
+	clock Clock
 
 	// s keeps a dynamic state for the place. See state constants for details
 	s state
+	// n is a number of tokens are received by the place from transitions or by Send. Tokens written directly to the
+	// strategy are not counted
+	n int64
+
+	// tokens keeps tokens are passed by the strategy and ready to be consumed by transitions. The place passes
+	// tokens until their number reaches capacity, which is the maximum weight of outgoing edges. Tokens are guarded by
+	// mu
+	tokens   []*M
+	capacity int
+	// drained means the place never passes tokens again. It is guarded by mu
+	drained bool
+	// closed means the place doesn't accept new tokens, so it passes the rest of tokens without waiting for a room. It
+	// is guarded by mu
+	closed bool
+	// room is signalled by transitions when they take tokens from the place
+	room chan struct{}
+	// consumers is a number of running transitions which consume, read or reset tokens of the place. The place doesn't
+	// wait for a room when there are no consumers left. It is guarded by mu
+	consumers int
+
+	// closing guards the strategy incoming channel from double closing
+	closing sync.Once
+	// done is closed when all place goroutines are completed. It is nil until the place is started
+	done chan struct{}
 }
 
 // NewP creates a new place with specific name. By default, place is both initial and terminal. When place is linked to
@@ -50,10 +95,18 @@ func NewP(name string) *P {
 	var p = &P{
 		name: name,
 
-		ins: skm.NewSKM(),
-		out: make(chan *M),
+		ins:       skm.NewSKM(),
+		outs:      skm.NewSKM(),
+		readers:   skm.NewSKM(),
+		resetters: skm.NewSKM(),
+		watchers:  skm.NewSKM(),
+		inhibited: skm.NewSKM(),
 
-		o: optionInitial | optionTerminal,
+		capacity: 1,
+		room:     make(chan struct{}, 1),
+
+		o:     optionInitial | optionTerminal,
+		clock: SystemClock{},
 	}
 	if trace.NeedLog(p.name) {
 		p.o |= optionLog
@@ -72,8 +125,35 @@ func (p *P) Name() string {
 	return p.name
 }
 
+// Ins returns names of transitions which produce tokens to the place sorted by name
+func (p *P) Ins() []string {
+	return keys(p.ins)
+}
+
+// Outs returns names of transitions which consume tokens from the place sorted by name
+func (p *P) Outs() []string {
+	return keys(p.outs)
+}
+
+// Initial returns true when the place doesn't have incoming edges
+func (p *P) Initial() bool {
+	return p.o&optionInitial > 0x0
+}
+
+// Terminal returns true when the place doesn't have outgoing edges
+func (p *P) Terminal() bool {
+	return p.o&optionTerminal > 0x0
+}
+
+// Close closes the place for incoming tokens. It is safe to call Close several times
 func (p *P) Close() {
-	close(p.strategy.In())
+	p.closing.Do(func() {
+		close(p.strategy.In())
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+		p.release()
+	})
 }
 
 func (p *P) In() chan<- *M {
@@ -92,11 +172,129 @@ func (p *P) Send(m *M) {
 		trace.Log(p.name, "[recv (direct)]", "v:", m.Value())
 	}
 	p.s.or(stateProcessing)
+	atomic.AddInt64(&p.n, 1)
 	p.In() <- m
 }
 
-func (p *P) ready() bool {
-	return p.s.state()&(stateReady|stateClosed) > 0x0
+// put passes the token to transitions and waits until the place has a room for the next token
+func (p *P) put(m *M) {
+	p.mu.Lock()
+	p.tokens = append(p.tokens, m)
+	full := p.full()
+	p.mu.Unlock()
+	p.notify()
+	for full {
+		<-p.room
+		p.mu.Lock()
+		full = p.full()
+		p.mu.Unlock()
+	}
+}
+
+// full returns true when the place passes enough tokens to transitions. It should be called under the place lock
+func (p *P) full() bool {
+	return len(p.tokens) >= p.capacity && p.consumers > 0 && !p.closed && p.o&optionEager == 0x0
+}
+
+// detach is called by a transition which never consumes, reads or resets tokens of the place again
+func (p *P) detach() {
+	p.mu.Lock()
+	p.consumers -= 1
+	p.mu.Unlock()
+	p.release()
+	p.uninhibit()
+}
+
+// take removes n first ready tokens. It should be called under the place lock
+func (p *P) take(n int) []*M {
+	var mm = make([]*M, n)
+	copy(mm, p.tokens)
+	p.tokens = append(p.tokens[:0], p.tokens[n:]...)
+	return mm
+}
+
+// pick removes ready tokens by ascending indices. It should be called under the place lock
+func (p *P) pick(ii []int) []*M {
+	var (
+		mm   = make([]*M, 0, len(ii))
+		j, k int
+	)
+	for i, m := range p.tokens {
+		if k < len(ii) && ii[k] == i {
+			mm, k = append(mm, m), k+1
+			continue
+		}
+		p.tokens[j], j = m, j+1
+	}
+	for i := j; i < len(p.tokens); i += 1 {
+		p.tokens[i] = nil
+	}
+	p.tokens = p.tokens[:j]
+	return mm
+}
+
+// read returns copies of n first ready tokens. Tokens stay in the place. It should be called under the place lock
+func (p *P) read(n int) []*M {
+	var mm = make([]*M, n)
+	for i, m := range p.tokens[:n] {
+		mm[i] = m.copy()
+	}
+	return mm
+}
+
+// held returns a number of tokens are held by the place. Terminal places hold received tokens, other places hold
+// tokens passed by the strategy. It should be called under the place lock
+func (p *P) held() int {
+	if p.o&optionTerminal > 0x0 {
+		return int(atomic.LoadInt64(&p.n))
+	}
+	return len(p.tokens)
+}
+
+// release signals the place has a room for new tokens
+func (p *P) release() {
+	select {
+	case p.room <- struct{}{}:
+	default:
+	}
+}
+
+// uninhibit wakes up all transitions inhibited by the place
+func (p *P) uninhibit() {
+	p.inhibited.Over(func(i int, n string, v interface{}) bool {
+		v.(*T).wake()
+		return true
+	})
+}
+
+// notify wakes up all transitions which consume, read or watch tokens of the place. It is called each time when the
+// place passes a token or drains
+func (p *P) notify() {
+	for _, sm := range []*skm.SKM{p.outs, p.readers, p.watchers} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			v.(*T).wake()
+			return true
+		})
+	}
+}
+
+// start runs place goroutines and returns a channel which is closed when all of them are completed
+func (p *P) start() <-chan struct{} {
+	p.consumers = p.outs.Len() + p.readers.Len() + p.resetters.Len()
+	p.done = make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+	for _, fn := range []func(){p.run, p.recv, p.send} {
+		go func(fn func()) {
+			defer wg.Done()
+			fn()
+		}(fn)
+	}
+	go func() {
+		wg.Wait()
+		close(p.done)
+	}()
+	return p.done
 }
 
 func (p *P) run() {
@@ -104,7 +302,7 @@ func (p *P) run() {
 		trace.Logf("%s [running...] o:%064b\n", p.name, p.o)
 		defer trace.Log(p.name, "[running completed]")
 	}
-	p.strategy.Run(p.ctx)
+	p.strategy.Run(ContextWithClock(p.ctx, p.clock))
 }
 
 func (p *P) recv() {
@@ -137,6 +335,7 @@ func (p *P) recv() {
 					trace.Log(p.name, "[recv]", "n:", n, "v:", m.Value())
 				}
 				p.s.or(stateProcessing)
+				atomic.AddInt64(&p.n, 1)
 				p.In() <- m
 			}
 		}()
@@ -144,10 +343,7 @@ func (p *P) recv() {
 	})
 	wg.Wait()
 
-	close(p.strategy.In())
-	if p.o&optionTerminal > 0x0 {
-		close(p.out)
-	}
+	p.Close()
 }
 
 func (p *P) send() {
@@ -173,6 +369,9 @@ func (p *P) send() {
 		}
 		return
 	}
+	// done is reset after the context is cancelled to avoid spinning over a closed channel while the place is still
+	// processing a token
+	done := p.ctx.Done()
 	for s := p.s.state(); (s&stateClosed)|(^s&stateProcessing) != (stateClosed | stateProcessing); s = p.s.state() {
 		select {
 		case m, ok := <-p.strategy.Out():
@@ -183,22 +382,24 @@ func (p *P) send() {
 				p.s.andnotor(stateProcessing, stateClosed)
 				break
 			}
-			p.s.andnotor(stateProcessing, stateReady)
+			p.s.andnot(stateProcessing)
 
 			m.passP(p)
 			if p.o&optionLog > 0x0 {
 				trace.Log(p.name, "[send]", "v:", m.Value())
 			}
-			p.out <- m
-
-			p.s.andnot(stateReady)
-			p.mu.Unlock()
-		case <-p.ctx.Done():
+			p.put(m)
+		case <-done:
+			done = nil
 			p.s.or(stateClosed)
 			if p.o&optionLog > 0x0 {
 				trace.Log(p.name, "[sending context deadline]")
 			}
 		}
 	}
-	close(p.out)
+	p.mu.Lock()
+	p.drained = true
+	p.mu.Unlock()
+	p.notify()
+	p.uninhibit()
 }
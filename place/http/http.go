@@ -13,3 +13,18 @@ func Processor(handler Handler) cpn.Transformation {
 		return m
 	}
 }
+
+// FallibleHandler processes the request and returns an error when processing fails
+type FallibleHandler func(ctx *RequestContext) error
+
+// FallibleProcessor creates a transformation which routes requests failed by the handler to the error place of
+// the transition
+func FallibleProcessor(handler FallibleHandler) cpn.FallibleTransformation {
+	return func(mm []*cpn.M) (*cpn.M, error) {
+		m := mm[0]
+		if err := handler(m.Value().(*RequestContext)); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+}
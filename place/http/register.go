@@ -0,0 +1,15 @@
+package http
+
+import "github.com/alxmsl/cpn"
+
+// Register registers HTTP strategies in the registry as `http.request` and `http.response`. Request accepts the
+// `address` and `pattern` options. Registered request has no cancel function, so a failure of its server just closes
+// the place
+func Register(r *cpn.Registry) {
+	r.AddStrategy("http.request", NewRequest)
+	r.AddStrategyOption("http.request", "address", cpn.StringOption(AddressOption))
+	r.AddStrategyOption("http.request", "pattern", cpn.StringOption(PatternOption))
+	r.AddStrategy("http.response", func(_ ...cpn.StrategyOption) cpn.Strategy {
+		return NewResponse()
+	})
+}
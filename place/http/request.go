@@ -73,8 +73,8 @@ func (p *Request) Run(_ context.Context) {
 		p.chout <- cpn.NewM(ctx)
 		ctx.Wait()
 	})
-	if err := http.ListenAndServe(p.addr, nil); err != http.ErrServerClosed {
+	// Server failure cancels the net context, when the cancel function is set. Otherwise the place is just closed
+	if err := http.ListenAndServe(p.addr, nil); err != http.ErrServerClosed && p.cancel != nil {
 		p.cancel()
-		return
 	}
 }
@@ -0,0 +1,30 @@
+package io
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alxmsl/cpn"
+)
+
+// Register registers the writer strategy in the registry as `io.writer`. Writer accepts the `writer` option with
+// `stdout` or `stderr` values
+func Register(r *cpn.Registry) {
+	r.AddStrategy("io.writer", NewWriter, WriterOption(os.Stdout))
+	r.AddStrategyOption("io.writer", "writer", decodeWriter)
+}
+
+func decodeWriter(raw json.RawMessage) (cpn.StrategyOption, error) {
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	switch v {
+	case "stdout":
+		return WriterOption(os.Stdout), nil
+	case "stderr":
+		return WriterOption(os.Stderr), nil
+	}
+	return nil, fmt.Errorf("unknown writer %q", v)
+}
@@ -0,0 +1,11 @@
+package memory
+
+import "github.com/alxmsl/cpn"
+
+// Register registers memory strategies in the registry as `memory.block` and `memory.queue`. Queue accepts the
+// `length` option
+func Register(r *cpn.Registry) {
+	r.AddStrategy("memory.block", NewBlock)
+	r.AddStrategy("memory.queue", NewQueue)
+	r.AddStrategyOption("memory.queue", "length", cpn.IntOption(LengthOption))
+}
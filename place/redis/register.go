@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alxmsl/cpn"
+	"github.com/mediocregopher/radix/v3"
+)
+
+// Register registers redis strategies in the registry as `redis.push` and `redis.pop` bound to the pool. Both accept
+// the `key` option, push accepts the `marshaller` option and pop accepts the `unmarshaller` option with `json` value.
+// Pop needs a value type, so it should be registered again with TypeOption
+func Register(r *cpn.Registry, pool *radix.Pool) {
+	r.AddStrategy("redis.push", NewPush, PoolOption(pool), MarshallerOption(JsonMarshal))
+	r.AddStrategyOption("redis.push", "key", cpn.StringOption(KeyOption))
+	r.AddStrategyOption("redis.push", "marshaller", decodeMarshaller)
+	r.AddStrategy("redis.pop", NewPop, PoolOption(pool), UnmarshallerOption(JsonUnmarshal))
+	r.AddStrategyOption("redis.pop", "key", cpn.StringOption(KeyOption))
+	r.AddStrategyOption("redis.pop", "unmarshaller", decodeUnmarshaller)
+}
+
+func decodeMarshaller(raw json.RawMessage) (cpn.StrategyOption, error) {
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v != "json" {
+		return nil, fmt.Errorf("unknown marshaller %q", v)
+	}
+	return MarshallerOption(JsonMarshal), nil
+}
+
+func decodeUnmarshaller(raw json.RawMessage) (cpn.StrategyOption, error) {
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v != "json" {
+		return nil, fmt.Errorf("unknown unmarshaller %q", v)
+	}
+	return UnmarshallerOption(JsonUnmarshal), nil
+}
@@ -1,8 +1,9 @@
 package cpn
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"sync/atomic"
 
 	"github.com/alxmsl/prmtvs/skm"
 )
@@ -12,12 +13,19 @@ const formatName = "%s:%d"
 type PN struct {
 	pp *skm.SKM
 	tt *skm.SKM
+
+	// handler receives tokens failed by transitions without error places
+	handler ErrorHandler
+	// clock is a source of time for places and transitions
+	clock Clock
 }
 
 func NewPN() *PN {
 	pn := &PN{
 		pp: skm.NewSKM(),
 		tt: skm.NewSKM(),
+
+		clock: SystemClock{},
 	}
 	return pn
 }
@@ -31,6 +39,26 @@ func (pn *PN) P(name string, opts ...PlaceOption) *P {
 	return p
 }
 
+// SetErrorHandler sets a handler for tokens failed by transitions without error places. For example, a transformation
+// panics. Handler should be set before the net is started. Handler receives errors of panicked guards as well, it is
+// called under places locks then
+func (pn *PN) SetErrorHandler(fn ErrorHandler) *PN {
+	pn.handler = fn
+	return pn
+}
+
+// SetClock sets a source of time for the net. Clock should be set before the net is started. Strategies get the clock
+// by ClockFromContext
+func (pn *PN) SetClock(c Clock) *PN {
+	pn.clock = c
+	return pn
+}
+
+// NewM creates a token with the value. Token creation time is measured by the clock of the net
+func (pn *PN) NewM(value interface{}) *M {
+	return newM(pn.clock, value)
+}
+
 func (pn *PN) Pn(n int, prefix string, opts ...PlaceOption) {
 	for i := 0; i < n; i += 1 {
 		name := fmt.Sprintf(formatName, prefix, i)
@@ -38,33 +66,91 @@ func (pn *PN) Pn(n int, prefix string, opts ...PlaceOption) {
 	}
 }
 
-func (pn *PN) PT(p, t string) *PN {
-	pn.T(t).ins.Add(pn.P(p).Name(), pn.P(p))
+func (pn *PN) PT(p, t string, opts ...ArcOption) *PN {
+	a := &arc{p: pn.P(p), w: 1}
+	for _, opt := range opts {
+		opt.Apply(a)
+	}
+	if pn.T(t).ins.Add(p, a) && a.w > a.p.capacity {
+		a.p.capacity = a.w
+	}
+	pn.P(p).outs.Add(pn.T(t).Name(), pn.T(t))
+	pn.P(p).o &= ^optionTerminal
+	return pn
+}
+
+// PTReset links the place to the transition by a reset edge. When the transition fires, it removes all tokens from the
+// place. Removed tokens are passed to the handler and to the place are defined by WithDiscard and WithDiscardPlace
+// options. The place passes all tokens of its strategy to transitions at once, so it doesn't keep tokens back
+func (pn *PN) PTReset(p, t string, opts ...ArcOption) *PN {
+	a := &arc{p: pn.P(p), w: 1}
+	for _, opt := range opts {
+		opt.Apply(a)
+	}
+	if a.to != "" {
+		a.d = pn.P(a.to)
+		a.d.ins.Add(pn.T(t).Name(), make(chan *M))
+		a.d.o &= ^optionInitial
+	}
+	pn.T(t).resets.Add(p, a)
+	pn.P(p).resetters.Add(pn.T(t).Name(), pn.T(t))
+	pn.P(p).o &= ^optionTerminal
+	pn.P(p).o |= optionEager
+	return pn
+}
+
+// PTInhibitor links the place to the transition by an inhibitor edge. Transition is enabled only when the place holds
+// less tokens than the edge weight. By default, the weight is one, so the place should be empty. The place passes all
+// tokens of its strategy at once, so tokens held by the strategy don't inhibit the transition
+func (pn *PN) PTInhibitor(p, t string, opts ...ArcOption) *PN {
+	a := &arc{p: pn.P(p), w: 1}
+	for _, opt := range opts {
+		opt.Apply(a)
+	}
+	pn.T(t).inhibitors.Add(p, a)
+	pn.P(p).inhibited.Add(pn.T(t).Name(), pn.T(t))
+	pn.P(p).o &= ^optionTerminal
+	pn.P(p).o |= optionEager
+	return pn
+}
+
+// PTRead links the place to the transition by a read edge. Transition is enabled only when the place holds at least as
+// many tokens as the edge weight. Transition reads tokens, but doesn't consume them, so many firings share the same
+// tokens
+func (pn *PN) PTRead(p, t string, opts ...ArcOption) *PN {
+	a := &arc{p: pn.P(p), w: 1}
+	for _, opt := range opts {
+		opt.Apply(a)
+	}
+	if pn.T(t).reads.Add(p, a) && a.w > a.p.capacity {
+		a.p.capacity = a.w
+	}
+	pn.P(p).readers.Add(pn.T(t).Name(), pn.T(t))
 	pn.P(p).o &= ^optionTerminal
 	return pn
 }
 
-func (pn *PN) PTn(n int, p, prefix string) *PN {
+func (pn *PN) PTn(n int, p, prefix string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		t := fmt.Sprintf(formatName, prefix, i)
-		pn.PT(p, t)
+		pn.PT(p, t, opts...)
 	}
 	return pn
 }
 
-func (pn *PN) PnTn(n int, prefixp, prefixt string) *PN {
+func (pn *PN) PnTn(n int, prefixp, prefixt string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		p := fmt.Sprintf(formatName, prefixp, i)
 		t := fmt.Sprintf(formatName, prefixt, i)
-		pn.PT(p, t)
+		pn.PT(p, t, opts...)
 	}
 	return pn
 }
 
-func (pn *PN) PnT(n int, prefixp, t string) *PN {
+func (pn *PN) PnT(n int, prefixp, t string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		p := fmt.Sprintf(formatName, prefixp, i)
-		pn.PT(p, t)
+		pn.PT(p, t, opts...)
 	}
 	return pn
 }
@@ -85,78 +171,262 @@ func (pn *PN) Tn(n int, prefix string, opts ...TransitionOption) {
 	}
 }
 
-func (pn *PN) TP(t, p string) *PN {
+func (pn *PN) TP(t, p string, opts ...ArcOption) *PN {
+	a := &arc{p: pn.P(p), w: 1}
+	for _, opt := range opts {
+		opt.Apply(a)
+	}
 	pn.P(p).ins.Add(pn.T(t).Name(), make(chan *M))
-	pn.T(t).outs.Add(pn.P(p).Name(), pn.P(p))
+	pn.T(t).outs.Add(p, a)
 	pn.P(p).o &= ^optionInitial
 	return pn
 }
 
-func (pn *PN) TPn(n int, t, prefixp string) *PN {
+// TPError links the transition to the error place. When the fallible transformation of the transition fails, consumed
+// tokens are passed to the error place. Error is available by M.Err
+func (pn *PN) TPError(t, p string) *PN {
+	pn.P(p).ins.Add(pn.T(t).Name(), make(chan *M))
+	pn.T(t).errs = &arc{p: pn.P(p), w: 1}
+	pn.P(p).o &= ^optionInitial
+	return pn
+}
+
+// TPTimeout links the transition to the timeout place. When the firing of the transition exceeds the timeout, consumed
+// tokens are passed to the timeout place. Without the timeout place such tokens are passed to the error place
+func (pn *PN) TPTimeout(t, p string) *PN {
+	pn.P(p).ins.Add(pn.T(t).Name(), make(chan *M))
+	pn.T(t).timeouts = &arc{p: pn.P(p), w: 1}
+	pn.P(p).o &= ^optionInitial
+	return pn
+}
+
+func (pn *PN) TPn(n int, t, prefixp string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		p := fmt.Sprintf(formatName, prefixp, i)
-		pn.TP(t, p)
+		pn.TP(t, p, opts...)
 	}
 	return pn
 }
 
-func (pn *PN) TnPn(n int, prefixt, prefixp string) *PN {
+func (pn *PN) TnPn(n int, prefixt, prefixp string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		t := fmt.Sprintf(formatName, prefixt, i)
 		p := fmt.Sprintf(formatName, prefixp, i)
-		pn.TP(t, p)
+		pn.TP(t, p, opts...)
 	}
 	return pn
 }
 
-func (pn *PN) TnP(n int, prefixt, p string) *PN {
+func (pn *PN) TnP(n int, prefixt, p string, opts ...ArcOption) *PN {
 	for i := 0; i < n; i += 1 {
 		t := fmt.Sprintf(formatName, prefixt, i)
-		pn.TP(t, p)
+		pn.TP(t, p, opts...)
 	}
 	return pn
 }
 
-func (pn *PN) Run() {
+// Validate checks the net structure. It returns ValidationError which contains all found problems
+func (pn *PN) Validate() error {
+	var err = &ValidationError{}
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		p := v.(*P)
+		if p.strategy == nil {
+			err.Errs = append(err.Errs, &StructureError{"place", n, ErrNoStrategy})
+		}
+		if p.ctx == nil {
+			err.Errs = append(err.Errs, &StructureError{"place", n, ErrNoContext})
+		}
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		t := v.(*T)
+		if t.ins.Len() == 0 {
+			err.Errs = append(err.Errs, &StructureError{"transition", n, ErrNoInputs})
+		}
+		if t.transformation == nil && t.router == nil && t.fallible == nil {
+			err.Errs = append(err.Errs, &StructureError{"transition", n, ErrNoTransformation})
+		}
+		if t.concurrency < 1 {
+			err.Errs = append(err.Errs, &StructureError{"transition", n, ErrInvalidConcurrency})
+		}
+		if t.fallible != nil && t.errs == nil && pn.handler == nil {
+			err.Errs = append(err.Errs, &StructureError{"transition", n, ErrNoErrorPlace})
+		}
+		for _, sm := range []*skm.SKM{t.ins, t.outs, t.reads, t.resets, t.inhibitors} {
+			sm.Over(func(i int, np string, v interface{}) bool {
+				if v.(*arc).w < 1 {
+					err.Errs = append(err.Errs, &StructureError{"transition", n,
+						fmt.Errorf("place %q: %w", np, ErrInvalidWeight)})
+				}
+				return true
+			})
+		}
+		return true
+	})
+	if len(err.Errs) > 0 {
+		return err
+	}
+	return nil
+}
+
+// Run validates the net and starts it
+func (pn *PN) Run() error {
+	if err := pn.Validate(); err != nil {
+		return err
+	}
+	pn.setup()
 	pn.pp.Over(func(i int, n string, v interface{}) bool {
-		go v.(*P).run()
-		go v.(*P).recv()
-		go v.(*P).send()
+		v.(*P).start()
 		return true
 	})
 	pn.tt.Over(func(i int, n string, v interface{}) bool {
-		go v.(*T).run()
+		v.(*T).start()
 		return true
 	})
+	return nil
 }
 
-func (pn *PN) RunSync() {
-	wg := sync.WaitGroup{}
+// setup builds lock sets of transitions and passes the error handler and the clock to places and transitions
+func (pn *PN) setup() {
 	pn.pp.Over(func(i int, n string, v interface{}) bool {
-		wg.Add(3)
-		go func() {
-			defer wg.Done()
-			v.(*P).run()
-		}()
-		go func() {
-			defer wg.Done()
-			v.(*P).recv()
-		}()
-		go func() {
-			defer wg.Done()
-			v.(*P).send()
-		}()
+		v.(*P).clock = pn.clock
 		return true
 	})
 	pn.tt.Over(func(i int, n string, v interface{}) bool {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			v.(*T).run()
-		}()
+		v.(*T).lockset()
+		v.(*T).handler = pn.handler
+		v.(*T).clock = pn.clock
+		v.(*T).calls = make(chan struct{}, v.(*T).concurrency)
+		// Places pass all tokens to guarded transitions, so the guard chooses among them
+		if v.(*T).guard != nil {
+			for _, sm := range []*skm.SKM{v.(*T).ins, v.(*T).reads} {
+				sm.Over(func(i int, n string, v interface{}) bool {
+					v.(*arc).p.o |= optionEager
+					return true
+				})
+			}
+		}
+		return true
+	})
+}
+
+// RunSync validates the net, starts it and waits until all places and transitions are completed
+func (pn *PN) RunSync() error {
+	if err := pn.Run(); err != nil {
+		return err
+	}
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		<-v.(*P).done
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		<-v.(*T).done
+		return true
+	})
+	return nil
+}
+
+// Shutdown closes all initial places and waits until tokens are drained through transitions to terminal places. When
+// the context is done before the net is quiesced, Shutdown returns ShutdownError with names of busy places and
+// transitions. When the net is quiesced, but some tokens were not drained, Shutdown returns ShutdownError with
+// ErrStrandedTokens, numbers of tokens left in places and numbers of tokens dropped by transitions
+func (pn *PN) Shutdown(ctx context.Context) error {
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		if v.(*P).o&optionInitial > 0x0 {
+			v.(*P).Close()
+		}
+		return true
+	})
+
+	var err = &ShutdownError{}
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		if !await(ctx, v.(*P).done) {
+			err.Places = append(err.Places, n)
+		}
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		if !await(ctx, v.(*T).done) {
+			err.Transitions = append(err.Transitions, n)
+		}
+		return true
+	})
+	if len(err.Places) > 0 || len(err.Transitions) > 0 {
+		err.Err = ctx.Err()
+		return err
+	}
+
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		p := v.(*P)
+		p.mu.Lock()
+		// Tokens of places which are only read by transitions are never consumed, so they are not stranded
+		if len(p.tokens) > 0 && p.outs.Len() > 0 {
+			err.Stranded = counts(err.Stranded, n, len(p.tokens))
+		}
+		p.mu.Unlock()
+		if d, ok := p.strategy.(Dropper); ok && d.Dropped() > 0 {
+			err.Dropped = counts(err.Dropped, n, d.Dropped())
+		}
+		return true
+	})
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		if d := atomic.LoadUint64(&v.(*T).dropped); d > 0 {
+			err.Dropped = counts(err.Dropped, n, int(d))
+		}
+		return true
+	})
+	if len(err.Stranded) > 0 || len(err.Dropped) > 0 {
+		err.Err = ErrStrandedTokens
+		return err
+	}
+	return nil
+}
+
+func counts(cc map[string]int, n string, c int) map[string]int {
+	if cc == nil {
+		cc = map[string]int{}
+	}
+	cc[n] += c
+	return cc
+}
+
+// await waits for the done channel until the context is done. Nil channel means not started entity, so it is not
+// awaited
+func await(ctx context.Context, done <-chan struct{}) bool {
+	if done == nil {
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Places returns all places of the net sorted by name
+func (pn *PN) Places() []*P {
+	var pp = make([]*P, 0, pn.pp.Len())
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		pp = append(pp, v.(*P))
+		return true
+	})
+	return pp
+}
+
+// Transitions returns all transitions of the net sorted by name
+func (pn *PN) Transitions() []*T {
+	var tt = make([]*T, 0, pn.tt.Len())
+	pn.tt.Over(func(i int, n string, v interface{}) bool {
+		tt = append(tt, v.(*T))
 		return true
 	})
-	wg.Wait()
+	return tt
 }
 
 func (pn *PN) Size() (int, int) {
@@ -0,0 +1,219 @@
+package pnml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alxmsl/cpn"
+)
+
+// Document is a decoded PNML document
+type Document struct {
+	pp []place
+	tt []transition
+	aa []arc
+}
+
+// Decode reads a PNML document. All nets and pages of the document are flattened into one net
+func Decode(r io.Reader) (*Document, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("pnml: %w", err)
+	}
+	var d = &Document{}
+	for _, n := range doc.Nets {
+		for _, pg := range n.Pages {
+			d.flatten(pg)
+		}
+	}
+	return d, nil
+}
+
+func (d *Document) flatten(pg page) {
+	d.pp = append(d.pp, pg.Places...)
+	d.tt = append(d.tt, pg.Transitions...)
+	d.aa = append(d.aa, pg.Arcs...)
+	for _, sub := range pg.Pages {
+		d.flatten(sub)
+	}
+}
+
+// Marking returns initial markings of places by place names. Places without tokens are omitted
+func (d *Document) Marking() (map[string]int, error) {
+	var mm = map[string]int{}
+	for _, p := range d.pp {
+		if p.InitialMarking == nil {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(p.InitialMarking.Text))
+		if err != nil {
+			return nil, fmt.Errorf("pnml: place %q: initial marking: %w", p.ID, err)
+		}
+		if n > 0 {
+			mm[name(p.ID, p.Name)] = n
+		}
+	}
+	return mm, nil
+}
+
+// Build creates a net from the document. Strategies and transformations are looked up in the registry by the tool
+// specific annotation at first, and then by the place or transition name. Elements which are not found by name are
+// left without strategy or transformation, so PN.Validate reports them. Place options are applied to all places
+func (d *Document) Build(reg *cpn.Registry, opts ...cpn.PlaceOption) (*cpn.PN, error) {
+	var (
+		pn = cpn.NewPN()
+		pp = map[string]string{}
+		tt = map[string]string{}
+	)
+	for _, p := range d.pp {
+		n := name(p.ID, p.Name)
+		oo := append([]cpn.PlaceOption{}, opts...)
+		s, annotated := annotation(p.ToolSpecific).strategy(n)
+		if o, ok := reg.Strategy(s); ok {
+			oo = append(oo, o)
+		} else if annotated {
+			return nil, fmt.Errorf("pnml: place %q: unknown strategy %q", n, s)
+		}
+		pn.P(n, oo...)
+		pp[p.ID] = n
+	}
+	for _, t := range d.tt {
+		n := name(t.ID, t.Name)
+		var oo []cpn.TransitionOption
+		s, annotated := annotation(t.ToolSpecific).transformation(n)
+		if o, ok := reg.Transformation(s); ok {
+			oo = append(oo, o)
+		} else if annotated {
+			return nil, fmt.Errorf("pnml: transition %q: unknown transformation %q", n, s)
+		}
+		too, err := annotation(t.ToolSpecific).timing()
+		if err != nil {
+			return nil, fmt.Errorf("pnml: transition %q: %w", n, err)
+		}
+		oo = append(oo, too...)
+		pn.T(n, oo...)
+		tt[t.ID] = n
+	}
+	for _, a := range d.aa {
+		var w = 1
+		if a.Inscription != nil {
+			var err error
+			w, err = strconv.Atoi(strings.TrimSpace(a.Inscription.Text))
+			if err != nil {
+				return nil, fmt.Errorf("pnml: arc %q: inscription: %w", a.ID, err)
+			}
+		}
+		if p, ok := pp[a.Source]; ok {
+			t, ok := tt[a.Target]
+			if !ok {
+				return nil, fmt.Errorf("pnml: arc %q: unknown transition %q", a.ID, a.Target)
+			}
+			switch {
+			case a.Type == nil || a.Type.Value == ArcNormal:
+				pn.PT(p, t, cpn.WithWeight(w))
+			case a.Type.Value == ArcRead:
+				pn.PTRead(p, t, cpn.WithWeight(w))
+			case a.Type.Value == ArcReset:
+				var oo []cpn.ArcOption
+				if id, ok := annotation(a.ToolSpecific).discard(); ok {
+					d, ok := pp[id]
+					if !ok {
+						return nil, fmt.Errorf("pnml: arc %q: unknown discard place %q", a.ID, id)
+					}
+					oo = append(oo, cpn.WithDiscardPlace(d))
+				}
+				pn.PTReset(p, t, oo...)
+			case a.Type.Value == ArcInhibitor:
+				pn.PTInhibitor(p, t, cpn.WithWeight(w))
+			default:
+				return nil, fmt.Errorf("pnml: arc %q: unknown type %q", a.ID, a.Type.Value)
+			}
+			continue
+		}
+		if t, ok := tt[a.Source]; ok {
+			p, ok := pp[a.Target]
+			if !ok {
+				return nil, fmt.Errorf("pnml: arc %q: unknown place %q", a.ID, a.Target)
+			}
+			switch {
+			case a.Type == nil || a.Type.Value == ArcNormal:
+				pn.TP(t, p, cpn.WithWeight(w))
+			case a.Type.Value == ArcError:
+				pn.TPError(t, p)
+			case a.Type.Value == ArcTimeout:
+				pn.TPTimeout(t, p)
+			default:
+				return nil, fmt.Errorf("pnml: arc %q: type %q is not allowed from transition", a.ID, a.Type.Value)
+			}
+			continue
+		}
+		return nil, fmt.Errorf("pnml: arc %q: unknown source %q", a.ID, a.Source)
+	}
+	return pn, nil
+}
+
+type annotation []toolSpecific
+
+func (a annotation) strategy(n string) (string, bool) {
+	for _, ts := range a {
+		if ts.Tool == Tool && ts.Strategy != "" {
+			return ts.Strategy, true
+		}
+	}
+	return n, false
+}
+
+func (a annotation) transformation(n string) (string, bool) {
+	for _, ts := range a {
+		if ts.Tool == Tool && ts.Transformation != "" {
+			return ts.Transformation, true
+		}
+	}
+	return n, false
+}
+
+func (a annotation) discard() (string, bool) {
+	for _, ts := range a {
+		if ts.Tool == Tool && ts.Discard != "" {
+			return ts.Discard, true
+		}
+	}
+	return "", false
+}
+
+// timing returns options for priority, concurrency, order, delay and timeout of the transition
+func (a annotation) timing() ([]cpn.TransitionOption, error) {
+	var oo []cpn.TransitionOption
+	for _, ts := range a {
+		if ts.Tool != Tool {
+			continue
+		}
+		if ts.Priority != 0 {
+			oo = append(oo, cpn.WithPriority(ts.Priority))
+		}
+		if ts.Concurrency != 0 {
+			oo = append(oo, cpn.WithConcurrency(ts.Concurrency))
+		}
+		if ts.Ordered {
+			oo = append(oo, cpn.WithOrdered(true))
+		}
+		for _, d := range []struct {
+			name, value string
+			option      func(time.Duration) cpn.TransitionOption
+		}{{"delay", ts.Delay, cpn.WithDelay}, {"timeout", ts.Timeout, cpn.WithTimeout}} {
+			if d.value == "" {
+				continue
+			}
+			v, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", d.name, err)
+			}
+			oo = append(oo, d.option(v))
+		}
+	}
+	return oo, nil
+}
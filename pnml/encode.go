@@ -0,0 +1,129 @@
+package pnml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alxmsl/cpn"
+)
+
+// Encode writes the net structure as a PNML document. Places and transitions get generated identifiers, their names
+// are kept in the name labels. Priority, concurrency, order, delay and timeout of transitions are kept in tool specific
+// annotations
+func Encode(w io.Writer, pn *cpn.PN) error {
+	var (
+		pg  = page{ID: "page"}
+		ids = map[string]string{}
+	)
+	for i, p := range pn.Places() {
+		id := fmt.Sprintf("p%d", i)
+		ids[p.Name()] = id
+		pg.Places = append(pg.Places, place{ID: id, Name: &text{p.Name()}})
+	}
+	for i, t := range pn.Transitions() {
+		id := fmt.Sprintf("t%d", i)
+		tr := transition{ID: id, Name: &text{t.Name()}}
+		if ts, ok := timing(t); ok {
+			tr.ToolSpecific = []toolSpecific{ts}
+		}
+		pg.Transitions = append(pg.Transitions, tr)
+		for _, p := range t.Ins() {
+			pg.Arcs = append(pg.Arcs, arc{
+				ID:          fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source:      ids[p],
+				Target:      id,
+				Inscription: inscription(t.InWeight(p)),
+			})
+		}
+		for _, p := range t.Reads() {
+			pg.Arcs = append(pg.Arcs, arc{
+				ID:          fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source:      ids[p],
+				Target:      id,
+				Inscription: inscription(t.ReadWeight(p)),
+				Type:        &value{ArcRead},
+			})
+		}
+		for _, p := range t.Resets() {
+			a := arc{
+				ID:     fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source: ids[p],
+				Target: id,
+				Type:   &value{ArcReset},
+			}
+			if d := t.ResetDiscard(p); d != "" {
+				a.ToolSpecific = []toolSpecific{{Tool: Tool, Version: ToolVersion, Discard: ids[d]}}
+			}
+			pg.Arcs = append(pg.Arcs, a)
+		}
+		for _, p := range t.Inhibitors() {
+			pg.Arcs = append(pg.Arcs, arc{
+				ID:          fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source:      ids[p],
+				Target:      id,
+				Inscription: inscription(t.InhibitorWeight(p)),
+				Type:        &value{ArcInhibitor},
+			})
+		}
+		for _, p := range t.Outs() {
+			pg.Arcs = append(pg.Arcs, arc{
+				ID:          fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source:      id,
+				Target:      ids[p],
+				Inscription: inscription(t.OutWeight(p)),
+			})
+		}
+		for _, e := range []struct{ p, kind string }{{t.Error(), ArcError}, {t.Timeout(), ArcTimeout}} {
+			if e.p == "" {
+				continue
+			}
+			pg.Arcs = append(pg.Arcs, arc{
+				ID:     fmt.Sprintf("a%d", len(pg.Arcs)),
+				Source: id,
+				Target: ids[e.p],
+				Type:   &value{e.kind},
+			})
+		}
+	}
+
+	var doc = document{
+		XMLNS: Namespace,
+		Nets:  []net{{ID: "net", Type: NetType, Pages: []page{pg}}},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	var e = xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// timing returns the annotation of the transition with its priority, concurrency, order, delay and timeout. It returns
+// false when all of them are default
+func timing(t *cpn.T) (toolSpecific, bool) {
+	var ts = toolSpecific{Tool: Tool, Version: ToolVersion, Priority: t.Priority(), Ordered: t.Ordered()}
+	if t.Concurrency() != 1 {
+		ts.Concurrency = t.Concurrency()
+	}
+	if t.Delay() != 0 {
+		ts.Delay = t.Delay().String()
+	}
+	if t.FiringTimeout() != 0 {
+		ts.Timeout = t.FiringTimeout().String()
+	}
+	return ts, ts != toolSpecific{Tool: Tool, Version: ToolVersion}
+}
+
+// inscription returns the arc weight label. Default weight is omitted
+func inscription(w int) *text {
+	if w == 1 {
+		return nil
+	}
+	return &text{strconv.Itoa(w)}
+}
@@ -0,0 +1,100 @@
+package pnml
+
+import "encoding/xml"
+
+const (
+	// Namespace is the PNML grammar namespace
+	Namespace = "http://www.pnml.org/version-2009/grammar/pnml"
+	// NetType is the place/transition net type
+	NetType = "http://www.pnml.org/version-2009/grammar/ptnet"
+
+	// Tool is a name of tool specific annotations which refer to registered strategies and transformations
+	Tool = "cpn"
+	// ToolVersion is a version of tool specific annotations
+	ToolVersion = "1.0"
+
+	// ArcNormal is a type of regular arcs. Arcs without type are regular
+	ArcNormal = "normal"
+	// ArcRead is a type of read arcs. Read arc goes from a place to a transition
+	ArcRead = "read"
+	// ArcReset is a type of reset arcs. Reset arc goes from a place to a transition
+	ArcReset = "reset"
+	// ArcInhibitor is a type of inhibitor arcs. Inhibitor arc goes from a place to a transition
+	ArcInhibitor = "inhibitor"
+	// ArcError is a type of error arcs. Error arc goes from a transition to a place
+	ArcError = "error"
+	// ArcTimeout is a type of timeout arcs. Timeout arc goes from a transition to a place
+	ArcTimeout = "timeout"
+)
+
+type document struct {
+	XMLName xml.Name `xml:"pnml"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Nets    []net    `xml:"net"`
+}
+
+type net struct {
+	ID    string `xml:"id,attr"`
+	Type  string `xml:"type,attr"`
+	Name  *text  `xml:"name,omitempty"`
+	Pages []page `xml:"page"`
+}
+
+type page struct {
+	ID          string       `xml:"id,attr"`
+	Places      []place      `xml:"place"`
+	Transitions []transition `xml:"transition"`
+	Arcs        []arc        `xml:"arc"`
+	Pages       []page       `xml:"page"`
+}
+
+type place struct {
+	ID             string         `xml:"id,attr"`
+	Name           *text          `xml:"name,omitempty"`
+	InitialMarking *text          `xml:"initialMarking,omitempty"`
+	ToolSpecific   []toolSpecific `xml:"toolspecific,omitempty"`
+}
+
+type transition struct {
+	ID           string         `xml:"id,attr"`
+	Name         *text          `xml:"name,omitempty"`
+	ToolSpecific []toolSpecific `xml:"toolspecific,omitempty"`
+}
+
+type arc struct {
+	ID          string `xml:"id,attr"`
+	Source      string `xml:"source,attr"`
+	Target      string `xml:"target,attr"`
+	Inscription *text  `xml:"inscription,omitempty"`
+	Type        *value `xml:"type,omitempty"`
+	// ToolSpecific refers to the discard place of a reset arc
+	ToolSpecific []toolSpecific `xml:"toolspecific,omitempty"`
+}
+
+type value struct {
+	Value string `xml:"value,attr"`
+}
+
+type text struct {
+	Text string `xml:"text"`
+}
+
+type toolSpecific struct {
+	Tool           string `xml:"tool,attr"`
+	Version        string `xml:"version,attr"`
+	Strategy       string `xml:"strategy,omitempty"`
+	Transformation string `xml:"transformation,omitempty"`
+	Discard        string `xml:"discard,omitempty"`
+	Priority       int    `xml:"priority,omitempty"`
+	Concurrency    int    `xml:"concurrency,omitempty"`
+	Ordered        bool   `xml:"ordered,omitempty"`
+	Delay          string `xml:"delay,omitempty"`
+	Timeout        string `xml:"timeout,omitempty"`
+}
+
+func name(id string, t *text) string {
+	if t == nil || t.Text == "" {
+		return id
+	}
+	return t.Text
+}
@@ -0,0 +1,134 @@
+package cpn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// OptionDecoder decodes a strategy option from the raw JSON value
+type OptionDecoder func(json.RawMessage) (StrategyOption, error)
+
+// IntOption creates a decoder for strategy options with integer values
+func IntOption(f func(int) StrategyOption) OptionDecoder {
+	return func(raw json.RawMessage) (StrategyOption, error) {
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return f(v), nil
+	}
+}
+
+// StringOption creates a decoder for strategy options with string values
+func StringOption(f func(string) StrategyOption) OptionDecoder {
+	return func(raw json.RawMessage) (StrategyOption, error) {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return f(v), nil
+	}
+}
+
+// Registry maps names to strategy builders and transformations. It is used to build nets from documents which refer
+// to strategies and transformations by name
+type Registry struct {
+	ss map[string]registryStrategy
+	tt map[string]TransitionOption
+	dd map[string]map[string]OptionDecoder
+}
+
+type registryStrategy struct {
+	builder StrategyBuilder
+	opts    []StrategyOption
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		ss: map[string]registryStrategy{},
+		tt: map[string]TransitionOption{},
+		dd: map[string]map[string]OptionDecoder{},
+	}
+}
+
+// AddStrategy registers the strategy builder under the name. Options are passed to the builder each time when a
+// strategy is created
+func (r *Registry) AddStrategy(name string, builder StrategyBuilder, opts ...StrategyOption) *Registry {
+	r.ss[name] = registryStrategy{builder, opts}
+	return r
+}
+
+// AddStrategyOption registers the decoder for the named option of the strategy. Registered strategy options are kept
+// when the strategy is registered again, so it is allowed to rebind non decodable options like connection pools
+func (r *Registry) AddStrategyOption(strategy, option string, dec OptionDecoder) *Registry {
+	if _, ok := r.dd[strategy]; !ok {
+		r.dd[strategy] = map[string]OptionDecoder{}
+	}
+	r.dd[strategy][option] = dec
+	return r
+}
+
+// AddTransformation registers the transformation under the name
+func (r *Registry) AddTransformation(name string, fn Transformation) *Registry {
+	r.tt[name] = WithTransformation(fn)
+	return r
+}
+
+// AddFallibleTransformation registers the fallible transformation under the name. It is referred as a transformation
+// by documents
+func (r *Registry) AddFallibleTransformation(name string, fn FallibleTransformation) *Registry {
+	r.tt[name] = WithFallibleTransformation(fn)
+	return r
+}
+
+// AddRouter registers the router under the name. Router is referred as a transformation by documents
+func (r *Registry) AddRouter(name string, fn Router) *Registry {
+	r.tt[name] = WithRouter(fn)
+	return r
+}
+
+// Strategy returns a place option which creates a new strategy registered under the name
+func (r *Registry) Strategy(name string) (PlaceOption, bool) {
+	s, ok := r.ss[name]
+	if !ok {
+		return nil, false
+	}
+	return WithStrategyBuilder(s.builder, s.opts...), true
+}
+
+// DecodeStrategy returns a place option which creates a new strategy registered under the name. Strategy is created
+// with registered options and options decoded from params
+func (r *Registry) DecodeStrategy(name string, params map[string]json.RawMessage) (PlaceOption, error) {
+	s, ok := r.ss[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+	var kk = make([]string, 0, len(params))
+	for k := range params {
+		kk = append(kk, k)
+	}
+	sort.Strings(kk)
+
+	var opts = append([]StrategyOption{}, s.opts...)
+	for _, k := range kk {
+		raw := params[k]
+		dec, ok := r.dd[name][k]
+		if !ok {
+			return nil, fmt.Errorf("strategy %q: unknown option %q", name, k)
+		}
+		opt, err := dec(raw)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %q: option %q: %w", name, k, err)
+		}
+		opts = append(opts, opt)
+	}
+	return WithStrategyBuilder(s.builder, opts...), nil
+}
+
+// Transformation returns a transition option which uses a transformation or a router registered under the name
+func (r *Registry) Transformation(name string) (TransitionOption, bool) {
+	o, ok := r.tt[name]
+	return o, ok
+}
@@ -0,0 +1,188 @@
+package cpn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is a declarative definition of a net
+type Spec struct {
+	Places      []PlaceSpec      `json:"places"`
+	Transitions []TransitionSpec `json:"transitions"`
+	Arcs        []ArcSpec        `json:"arcs"`
+}
+
+// PlaceSpec defines a place with a registered strategy and its options
+type PlaceSpec struct {
+	Name     string                     `json:"name"`
+	Strategy string                     `json:"strategy"`
+	Options  map[string]json.RawMessage `json:"options,omitempty"`
+	Keep     bool                       `json:"keep,omitempty"`
+}
+
+// TransitionSpec defines a transition with a registered transformation. Priority, concurrency, order, delay and
+// timeout are optional. Zero concurrency means the default concurrency. Delay and timeout are durations like "1.5s"
+type TransitionSpec struct {
+	Name           string `json:"name"`
+	Transformation string `json:"transformation"`
+	Priority       int    `json:"priority,omitempty"`
+	Concurrency    int    `json:"concurrency,omitempty"`
+	Ordered        bool   `json:"ordered,omitempty"`
+	Delay          string `json:"delay,omitempty"`
+	Timeout        string `json:"timeout,omitempty"`
+}
+
+// ArcSpec defines an edge between a place and a transition in any direction. Zero weight means the default weight.
+// Empty kind means a regular edge. Kinds "read", "reset" and "inhibitor" mean special edges from a place to
+// a transition. Discard is a place which receives tokens removed by a reset edge. Kinds "error" and "timeout" mean
+// edges from a transition to its error and timeout places
+type ArcSpec struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Weight  int    `json:"weight,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Discard string `json:"discard,omitempty"`
+}
+
+// Kinds of edges in the net definition
+const (
+	ArcRead      = "read"
+	ArcReset     = "reset"
+	ArcInhibitor = "inhibitor"
+	ArcError     = "error"
+	ArcTimeout   = "timeout"
+)
+
+// DecodeJSON reads a net definition in JSON
+func DecodeJSON(r io.Reader) (*Spec, error) {
+	var spec = &Spec{}
+	var dec = json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	return spec, nil
+}
+
+// DecodeYAML reads a net definition in YAML. Document has the same structure as JSON one, and unknown fields are
+// rejected as well
+func DecodeYAML(r io.Reader) (*Spec, error) {
+	var v interface{}
+	if err := yaml.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	return DecodeJSON(bytes.NewReader(bb))
+}
+
+// Load builds a net from the definition. Strategies and transformations are looked up in the registry. Places use the
+// background context, unless the context option is passed. Place options are applied to all places
+func Load(spec *Spec, reg *Registry, opts ...PlaceOption) (*PN, error) {
+	var (
+		pn = NewPN()
+		pp = map[string]struct{}{}
+		tt = map[string]struct{}{}
+	)
+	for _, ps := range spec.Places {
+		if _, ok := pp[ps.Name]; ok {
+			return nil, fmt.Errorf("spec: place %q: duplicated", ps.Name)
+		}
+		s, err := reg.DecodeStrategy(ps.Strategy, ps.Options)
+		if err != nil {
+			return nil, fmt.Errorf("spec: place %q: %w", ps.Name, err)
+		}
+		oo := append([]PlaceOption{WithContext(context.Background())}, opts...)
+		pn.P(ps.Name, append(oo, s, WithKeep(ps.Keep))...)
+		pp[ps.Name] = struct{}{}
+	}
+	for _, ts := range spec.Transitions {
+		if _, ok := tt[ts.Name]; ok {
+			return nil, fmt.Errorf("spec: transition %q: duplicated", ts.Name)
+		}
+		if _, ok := pp[ts.Name]; ok {
+			return nil, fmt.Errorf("spec: transition %q: place with the same name exists", ts.Name)
+		}
+		o, ok := reg.Transformation(ts.Transformation)
+		if !ok {
+			return nil, fmt.Errorf("spec: transition %q: unknown transformation %q", ts.Name, ts.Transformation)
+		}
+		oo := []TransitionOption{o, WithPriority(ts.Priority), WithOrdered(ts.Ordered)}
+		if ts.Concurrency != 0 {
+			oo = append(oo, WithConcurrency(ts.Concurrency))
+		}
+		for _, d := range []struct {
+			name, value string
+			option      func(time.Duration) TransitionOption
+		}{{"delay", ts.Delay, WithDelay}, {"timeout", ts.Timeout, WithTimeout}} {
+			if d.value == "" {
+				continue
+			}
+			v, err := time.ParseDuration(d.value)
+			if err != nil {
+				return nil, fmt.Errorf("spec: transition %q: %s: %w", ts.Name, d.name, err)
+			}
+			oo = append(oo, d.option(v))
+		}
+		pn.T(ts.Name, oo...)
+		tt[ts.Name] = struct{}{}
+	}
+	for _, as := range spec.Arcs {
+		var oo []ArcOption
+		if as.Weight != 0 {
+			oo = append(oo, WithWeight(as.Weight))
+		}
+		_, fromp := pp[as.From]
+		_, fromt := tt[as.From]
+		_, top := pp[as.To]
+		_, tot := tt[as.To]
+		switch as.Kind {
+		case "":
+		case ArcRead, ArcReset, ArcInhibitor:
+			if !fromp || !tot {
+				return nil, fmt.Errorf("spec: arc %q -> %q: %s must go from a place to a transition",
+					as.From, as.To, as.Kind)
+			}
+		case ArcError, ArcTimeout:
+			if !fromt || !top {
+				return nil, fmt.Errorf("spec: arc %q -> %q: %s must go from a transition to a place",
+					as.From, as.To, as.Kind)
+			}
+		default:
+			return nil, fmt.Errorf("spec: arc %q -> %q: unknown kind %q", as.From, as.To, as.Kind)
+		}
+		switch {
+		case as.Kind == ArcRead:
+			pn.PTRead(as.From, as.To, oo...)
+		case as.Kind == ArcReset:
+			if as.Discard != "" {
+				if _, ok := pp[as.Discard]; !ok {
+					return nil, fmt.Errorf("spec: arc %q -> %q: unknown discard place %q", as.From, as.To, as.Discard)
+				}
+				oo = append(oo, WithDiscardPlace(as.Discard))
+			}
+			pn.PTReset(as.From, as.To, oo...)
+		case as.Kind == ArcInhibitor:
+			pn.PTInhibitor(as.From, as.To, oo...)
+		case as.Kind == ArcError:
+			pn.TPError(as.From, as.To)
+		case as.Kind == ArcTimeout:
+			pn.TPTimeout(as.From, as.To)
+		case fromp && tot:
+			pn.PT(as.From, as.To, oo...)
+		case fromt && top:
+			pn.TP(as.From, as.To, oo...)
+		default:
+			return nil, fmt.Errorf("spec: arc %q -> %q: must connect a place and a transition", as.From, as.To)
+		}
+	}
+	return pn, nil
+}
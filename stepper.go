@@ -0,0 +1,291 @@
+package cpn
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSettle is a default duration the stepper waits for tokens from a place strategy
+const defaultSettle = 10 * time.Millisecond
+
+// Policy chooses a transition to fire among enabled transitions. Enabled transitions are sorted by name
+type Policy func(enabled []string) (string, error)
+
+// FirstPolicy creates a policy which chooses the first enabled transition by name
+func FirstPolicy() Policy {
+	return func(enabled []string) (string, error) {
+		return enabled[0], nil
+	}
+}
+
+// RandomPolicy creates a policy which chooses a random enabled transition. Policies with the same seed make the same
+// choices
+func RandomPolicy(seed int64) Policy {
+	var r = rand.New(rand.NewSource(seed))
+	return func(enabled []string) (string, error) {
+		return enabled[r.Intn(len(enabled))], nil
+	}
+}
+
+// ScriptPolicy creates a policy which chooses transitions in the order of names. It fails when the transition is not
+// enabled or the script is over
+func ScriptPolicy(names ...string) Policy {
+	return func(enabled []string) (string, error) {
+		if len(names) == 0 {
+			return "", ErrScriptOver
+		}
+		var n = names[0]
+		names = names[1:]
+		return n, nil
+	}
+}
+
+// StepperOption is an abstraction to define stepper options
+type StepperOption interface {
+	Apply(*Stepper)
+}
+
+// WithPolicy creates an option to set a policy of the stepper. Stepper uses FirstPolicy by default
+func WithPolicy(p Policy) StepperOption {
+	return policyOpt{p}
+}
+
+type policyOpt struct {
+	p Policy
+}
+
+func (o policyOpt) Apply(s *Stepper) {
+	s.policy = o.p
+}
+
+// WithSettle creates an option to set a duration the stepper waits for tokens from a place strategy, which receives
+// more tokens than it passes. The duration is measured by the wall clock, so a manual clock of the net doesn't block
+// the stepper
+func WithSettle(d time.Duration) StepperOption {
+	return settleOpt{d}
+}
+
+type settleOpt struct {
+	d time.Duration
+}
+
+func (o settleOpt) Apply(s *Stepper) {
+	s.settle = o.d
+}
+
+// Stepper is a deterministic single-threaded executor of the net. It fires one enabled transition at a time, which is
+// chosen by the policy. Tokens are passed through place strategies, as when the net is running, so strategies run
+// in their own goroutines. Each time a token is put to a place, stepper waits until the strategy passes it. Stepper
+// is not safe for concurrent use, and the net should not be run
+type Stepper struct {
+	pn     *PN
+	policy Policy
+	settle time.Duration
+
+	// pending keeps numbers of tokens are received by place strategies, but not passed yet
+	pending map[*P]int
+	// err is the first error of the current step
+	err error
+}
+
+// NewStepper validates the net and starts strategies of its places
+func NewStepper(pn *PN, opts ...StepperOption) (*Stepper, error) {
+	if err := pn.Validate(); err != nil {
+		return nil, err
+	}
+	var s = &Stepper{
+		pn:     pn,
+		policy: FirstPolicy(),
+		settle: defaultSettle,
+
+		pending: map[*P]int{},
+	}
+	for _, opt := range opts {
+		opt.Apply(s)
+	}
+	pn.setup()
+	pn.pp.Over(func(i int, n string, v interface{}) bool {
+		go v.(*P).run()
+		return true
+	})
+	return s, nil
+}
+
+// Put puts the token to the place and waits until the place strategy passes it
+func (s *Stepper) Put(p string, m *M) error {
+	s.err = nil
+	s.put(s.pn.P(p), m)
+	return s.err
+}
+
+// Close closes the place for incoming tokens and collects the rest of tokens of its strategy. Transitions which
+// consume tokens from the place never fire when the place has not enough tokens
+func (s *Stepper) Close(p string) {
+	var pl = s.pn.P(p)
+	if pl.closed {
+		return
+	}
+	pl.closed = true
+	close(pl.strategy.In())
+	for m := range pl.strategy.Out() {
+		s.pass(pl, m)
+	}
+	pl.drained = true
+}
+
+// Enabled returns names of transitions which may fire sorted by name. Transition with a delay may fire when it stays
+// enabled for the delay by the clock of the net, which is counted from the first call that finds it enabled
+func (s *Stepper) Enabled() []string {
+	s.collect()
+	var tt []string
+	s.pn.tt.Over(func(i int, n string, v interface{}) bool {
+		t := v.(*T)
+		ready, _, err := t.enabled()
+		if err != nil {
+			t.report(nil, err)
+		}
+		if !ready || t.overridden() {
+			t.since = time.Time{}
+			return true
+		}
+		if t.wait() <= 0 {
+			tt = append(tt, n)
+		}
+		return true
+	})
+	return tt
+}
+
+// Step fires one enabled transition chosen by the policy and returns its name. It returns ErrNoEnabledTransitions
+// when no transition is enabled
+func (s *Stepper) Step() (string, error) {
+	var enabled = s.Enabled()
+	if len(enabled) == 0 {
+		return "", ErrNoEnabledTransitions
+	}
+	n, err := s.policy(enabled)
+	if err != nil {
+		return "", err
+	}
+	var found bool
+	for _, e := range enabled {
+		found = found || e == n
+	}
+	if !found {
+		return "", fmt.Errorf("transition %q: %w", n, ErrNotEnabled)
+	}
+
+	s.err = nil
+	var t = s.pn.T(n)
+	t.since = time.Time{}
+	var mm, mmm = t.instake(), t.insreset()
+	t.discard(mmm, s.put)
+	m, out, err := t.fire(mm)
+	t.send(mm, m, out, err, s.put)
+	return n, s.err
+}
+
+// Marking returns tokens which are passed by place strategies by place names. Tokens of terminal places are kept
+// there, instead of being passed to Out. Places without tokens are omitted
+func (s *Stepper) Marking() map[string][]*M {
+	s.collect()
+	var mm = map[string][]*M{}
+	s.pn.pp.Over(func(i int, n string, v interface{}) bool {
+		if p := v.(*P); len(p.tokens) > 0 {
+			mm[n] = append([]*M{}, p.tokens...)
+		}
+		return true
+	})
+	return mm
+}
+
+// put passes the token to the place strategy and collects tokens passed by the strategy. It waits until the strategy
+// passes as many tokens as it receives, or it doesn't pass tokens for the settle duration
+func (s *Stepper) put(p *P, m *M) {
+	if p.closed {
+		if s.err == nil {
+			s.err = fmt.Errorf("place %q: %w", p.name, ErrPlaceClosed)
+		}
+		return
+	}
+	m.passP(p)
+	atomic.AddInt64(&p.n, 1)
+	s.pending[p] += 1
+
+	var accepted = make(chan struct{})
+	go func() {
+		p.strategy.In() <- m
+		close(accepted)
+	}()
+	var (
+		timer *time.Timer
+		quiet <-chan time.Time
+	)
+	// settle restarts the quiet period, which is measured by the wall clock
+	var settle = func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(s.settle)
+		quiet = timer.C
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for accepted != nil || s.pending[p] > 0 {
+		select {
+		case m, ok := <-p.strategy.Out():
+			if !ok {
+				p.drained = true
+				return
+			}
+			s.pass(p, m)
+			if accepted == nil {
+				settle()
+			}
+		case <-accepted:
+			accepted = nil
+			settle()
+		case <-quiet:
+			return
+		}
+	}
+}
+
+// collect takes tokens which are passed by place strategies without waiting
+func (s *Stepper) collect() {
+	s.pn.pp.Over(func(i int, n string, v interface{}) bool {
+		p := v.(*P)
+		for !p.drained && s.poll(p) {
+		}
+		return true
+	})
+}
+
+// poll takes a token passed by the place strategy without waiting. It returns false when there is no token
+func (s *Stepper) poll(p *P) bool {
+	select {
+	case m, ok := <-p.strategy.Out():
+		if !ok {
+			p.drained = true
+			return false
+		}
+		s.pass(p, m)
+		return true
+	default:
+		return false
+	}
+}
+
+// pass makes the token passed by the place strategy ready to be consumed by transitions
+func (s *Stepper) pass(p *P, m *M) {
+	m.passP(p)
+	p.tokens = append(p.tokens, m)
+	if s.pending[p] > 0 {
+		s.pending[p] -= 1
+	}
+}
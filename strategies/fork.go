@@ -2,6 +2,7 @@ package strategies
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/alxmsl/cpn"
 )
@@ -13,7 +14,9 @@ type fork struct {
 	chin  chan *cpn.M
 	chout chan *cpn.M
 
-	f ForkFunc
+	dropped int64
+	errs    chan<- error
+	f       ForkFunc
 }
 
 // ForkFuncOption creates a 1->m strategy option for a place
@@ -50,9 +53,30 @@ func (p *fork) Out() <-chan *cpn.M {
 	return p.chout
 }
 
+// SetErrs sets a channel for errors of the strategy. Panics are recovered and reported as *cpn.PanicError
+func (p *fork) SetErrs(errs chan<- error) {
+	p.errs = errs
+}
+
+// Dropped returns a number of panicked tokens
+func (p *fork) Dropped() int {
+	return int(atomic.LoadInt64(&p.dropped))
+}
+
 func (p *fork) Run(ctx context.Context) {
 	defer close(p.chout)
 	for m := range p.chin {
-		p.f(ctx, m, p.chout)
+		p.call(ctx, m)
 	}
 }
+
+// call passes the token to the function. Tokens passed before a panic are kept
+func (p *fork) call(ctx context.Context, m *cpn.M) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&p.dropped, 1)
+			report(p.errs, cpn.NewPanicError(r, m))
+		}
+	}()
+	p.f(ctx, m, p.chout)
+}
@@ -2,6 +2,7 @@ package strategies
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/alxmsl/cpn"
 )
@@ -13,7 +14,9 @@ type join struct {
 	chin  chan *cpn.M
 	chout chan *cpn.M
 
-	f JoinFunc
+	dropped int64
+	errs    chan<- error
+	f       JoinFunc
 }
 
 // JoinFuncOption creates a m->1 strategy option for a place
@@ -50,7 +53,35 @@ func (p *join) Out() <-chan *cpn.M {
 	return p.chout
 }
 
+// SetErrs sets a channel for errors of the strategy. Panics are recovered and reported as *cpn.PanicError
+func (p *join) SetErrs(errs chan<- error) {
+	p.errs = errs
+}
+
+// Dropped returns a number of tokens lost by a panic: the joined token and incoming tokens skipped after the panic
+func (p *join) Dropped() int {
+	return int(atomic.LoadInt64(&p.dropped))
+}
+
 func (p *join) Run(ctx context.Context) {
 	defer close(p.chout)
-	p.chout <- p.f(ctx, p.chin)
+	if m, ok := p.call(ctx); ok {
+		p.chout <- m
+		return
+	}
+	// Incoming tokens are skipped, so the place isn't blocked after the panic
+	for range p.chin {
+		atomic.AddInt64(&p.dropped, 1)
+	}
+}
+
+// call passes incoming tokens to the function. Nothing is passed forward when the function panics
+func (p *join) call(ctx context.Context) (_ *cpn.M, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&p.dropped, 1)
+			report(p.errs, cpn.NewPanicError(r, nil))
+		}
+	}()
+	return p.f(ctx, p.chin), true
 }
@@ -2,6 +2,7 @@ package strategies
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/alxmsl/cpn"
 )
@@ -13,7 +14,9 @@ type pass struct {
 	chin  chan *cpn.M
 	chout chan *cpn.M
 
-	f PassFunc
+	dropped int64
+	errs    chan<- error
+	f       PassFunc
 }
 
 // PassFuncOption creates a 1->1 strategy option for a place
@@ -50,9 +53,40 @@ func (p *pass) Out() <-chan *cpn.M {
 	return p.chout
 }
 
+// SetErrs sets a channel for errors of the strategy. Panics are recovered and reported as *cpn.PanicError
+func (p *pass) SetErrs(errs chan<- error) {
+	p.errs = errs
+}
+
+// Dropped returns a number of panicked tokens
+func (p *pass) Dropped() int {
+	return int(atomic.LoadInt64(&p.dropped))
+}
+
 func (p *pass) Run(ctx context.Context) {
 	defer close(p.chout)
 	for m := range p.chin {
-		p.chout <- p.f(ctx, m)
+		if m, ok := p.call(ctx, m); ok {
+			p.chout <- m
+		}
+	}
+}
+
+// call passes the token to the function. Panicked token is dropped
+func (p *pass) call(ctx context.Context, m *cpn.M) (_ *cpn.M, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&p.dropped, 1)
+			report(p.errs, cpn.NewPanicError(r, m))
+		}
+	}()
+	return p.f(ctx, m), true
+}
+
+// report passes the error to the errors channel. Error is skipped when nobody awaits it
+func report(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
 	}
 }
@@ -1,16 +1,55 @@
 package cpn
 
 import (
-	"runtime"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/alxmsl/cpn/trace"
 
 	"github.com/alxmsl/prmtvs/skm"
 )
 
-// Transformation defines a custom behaviour for a transition
+// Transformation defines a custom behaviour for a transition. Tokens are grouped by incoming places sorted by name.
+// Each place passes as many tokens as the weight of its edge. Copies of read tokens follow consumed tokens, they are
+// grouped by read places sorted by name too
 type Transformation func(in []*M) *M
 
+// FallibleTransformation defines a custom behaviour for a transition which may fail. When it returns an error, the
+// transition passes consumed tokens with the attached error to the error place. See PN.TPError
+type FallibleTransformation func(in []*M) (*M, error)
+
+// ContextTransformation defines a custom behaviour for a transition which may fail or be cancelled. Context is
+// cancelled when the firing exceeds the transition timeout. See WithTimeout. Context carries the clock of the net, see
+// ClockFromContext
+type ContextTransformation func(ctx context.Context, in []*M) (*M, error)
+
+// ErrorHandler receives a failed token and the error. Token is nil when the failure is not related to a token
+type ErrorHandler func(m *M, err error)
+
+// Router defines a custom behaviour for a transition which passes different tokens to different outgoing places.
+// Incoming tokens are grouped as for Transformation. Returned map is keyed by outgoing place names. Places which are
+// missing in the map or have nil tokens don't get tokens. Consumed tokens are counted as dropped, when no place gets
+// them
+type Router func(in []*M) map[string]*M
+
+// arc is an edge between a place and a transition
+type arc struct {
+	p *P
+	// w is a number of tokens consumed or produced by the transition
+	w int
+	// n is a number of tokens passed through the edge
+	n uint64
+
+	// discard handles tokens removed by a reset edge
+	discard func(*M)
+	// to is a name of the place which receives tokens removed by a reset edge. d is the place itself
+	to string
+	d  *P
+}
+
 // T implements an abstract transition in PN
 type T struct {
 	// name is a transition name in the PN. This is good to have it unique
@@ -19,11 +58,65 @@ type T struct {
 	// transformation defines behaviour for the transition. Transition awaits tokens from each incoming edge. All tokens
 	// are passed to the transformation. Transformation returns a token which will be passed to the following places
 	transformation Transformation
+	// router defines behaviour for the transition instead of the transformation, when it is set
+	router Router
+	// fallible defines behaviour for the transition instead of the transformation, when it is set. Failed tokens are
+	// passed to the error edge
+	fallible ContextTransformation
+	errs     *arc
+	// delay is a minimum duration the transition should be enabled before it fires. since is a time when the
+	// transition became enabled
+	delay time.Duration
+	since time.Time
+	// timeout is a maximum duration of the firing. Tokens of timed out firings are passed to the timeout edge
+	timeout  time.Duration
+	timeouts *arc
+	// calls bounds a number of running calls of the timed transition by the concurrency. Timed out calls keep their
+	// slots until they return. It is built when the net is started
+	calls chan struct{}
+	// handler receives failed tokens when the transition has no error place. It is set by the net
+	handler ErrorHandler
+	// clock measures delays, timeouts and times when tokens pass the transition. It is set by the net
+	clock Clock
+	// guard is a predicate over tokens which would be passed to the transformation. Transition fires only when the guard
+	// accepts the tokens. Nil guard accepts any tokens
+	guard func([]*M) bool
+	// binding keeps indices of tokens accepted by the guard, in the order of incoming and read edges. It is built when
+	// the transition is checked under places locks
+	binding []int
+	// concurrency is a maximum number of firings which run in parallel. ordered means tokens are passed to outgoing
+	// places in the order of firings
+	concurrency int
+	ordered     bool
+	// priority resolves conflicts between transitions which consume tokens from the same place. Transition doesn't
+	// fire while a conflicting transition with a higher priority is enabled
+	priority int
 
 	// ins is a sorted set of incoming edges
 	ins *skm.SKM
 	// outs is a sorted set of outgoing edges
 	outs *skm.SKM
+	// reads is a sorted set of read edges. Transition is enabled only when places of these edges hold enough tokens,
+	// but the tokens are not consumed
+	reads *skm.SKM
+	// resets is a sorted set of reset edges. Transition removes all tokens from places of these edges when it fires
+	resets *skm.SKM
+	// inhibitors is a sorted set of inhibitor edges. Transition is enabled only when places of these edges hold less
+	// tokens than edge weights
+	inhibitors *skm.SKM
+	// locks is a list of all places are checked by the transition sorted by name. It is built when the net is started
+	locks []*P
+	// over is a list of conflicting transitions with a higher priority. It is built when the net is started
+	over []*T
+	// dropped is a number of consumed tokens for which the transformation returned no token, or failed tokens without
+	// an error place and an error handler
+	dropped uint64
+
+	// wakeup is signalled by incoming places when their state is changed. Transition sleeps on it while it is not
+	// enabled
+	wakeup chan struct{}
+	// done is closed when the transition goroutine is completed. It is nil until the transition is started
+	done chan struct{}
 
 	// o keeps a static options flags for an abstract transition. See options constants for details
 	o uint64
@@ -34,8 +127,16 @@ func NewT(name string) *T {
 	var t = &T{
 		name: name,
 
-		ins:  skm.NewSKM(),
-		outs: skm.NewSKM(),
+		ins:        skm.NewSKM(),
+		outs:       skm.NewSKM(),
+		reads:      skm.NewSKM(),
+		resets:     skm.NewSKM(),
+		inhibitors: skm.NewSKM(),
+
+		wakeup: make(chan struct{}, 1),
+
+		concurrency: 1,
+		clock:       SystemClock{},
 	}
 	if trace.NeedLog(t.name) {
 		t.o &= optionLog
@@ -54,31 +155,559 @@ func (t *T) Name() string {
 	return t.name
 }
 
+// Ins returns names of incoming places sorted by name
+func (t *T) Ins() []string {
+	return keys(t.ins)
+}
+
+// Outs returns names of outgoing places sorted by name
+func (t *T) Outs() []string {
+	return keys(t.outs)
+}
+
+// Reads returns names of places which are read by the transition sorted by name
+func (t *T) Reads() []string {
+	return keys(t.reads)
+}
+
+// ReadWeight returns a weight of the read edge from the place, or zero when there is no such edge
+func (t *T) ReadWeight(p string) int {
+	return weight(t.reads, p)
+}
+
+// Timeout returns a name of the timeout place, or empty string when there is no such place
+func (t *T) Timeout() string {
+	if t.timeouts == nil {
+		return ""
+	}
+	return t.timeouts.p.name
+}
+
+// Concurrency returns a maximum number of firings of the transition which run in parallel
+func (t *T) Concurrency() int {
+	return t.concurrency
+}
+
+// Ordered returns true when tokens of concurrent firings are passed in the order which tokens are consumed in
+func (t *T) Ordered() bool {
+	return t.ordered
+}
+
+// Delay returns a duration the transition should be enabled before it fires
+func (t *T) Delay() time.Duration {
+	return t.delay
+}
+
+// FiringTimeout returns a maximum duration of each firing, or zero when firings are not limited
+func (t *T) FiringTimeout() time.Duration {
+	return t.timeout
+}
+
+// Error returns a name of the error place, or empty string when there is no such place
+func (t *T) Error() string {
+	if t.errs == nil {
+		return ""
+	}
+	return t.errs.p.name
+}
+
+// Priority returns a priority of the transition
+func (t *T) Priority() int {
+	return t.priority
+}
+
+// Resets returns names of places which are reset by the transition sorted by name
+func (t *T) Resets() []string {
+	return keys(t.resets)
+}
+
+// ResetDiscard returns a name of the place which receives tokens removed by the reset edge from the place, or empty
+// string when there is no such place
+func (t *T) ResetDiscard(p string) string {
+	if v, ok := t.resets.GetByKey(p); ok {
+		return v.(*arc).to
+	}
+	return ""
+}
+
+// Inhibitors returns names of places which inhibit the transition sorted by name
+func (t *T) Inhibitors() []string {
+	return keys(t.inhibitors)
+}
+
+// InhibitorWeight returns a weight of the inhibitor edge from the place, or zero when there is no such edge
+func (t *T) InhibitorWeight(p string) int {
+	return weight(t.inhibitors, p)
+}
+
+// InWeight returns a weight of the edge from the place, or zero when there is no such edge
+func (t *T) InWeight(p string) int {
+	return weight(t.ins, p)
+}
+
+// OutWeight returns a weight of the edge to the place, or zero when there is no such edge
+func (t *T) OutWeight(p string) int {
+	return weight(t.outs, p)
+}
+
+func weight(sm *skm.SKM, p string) int {
+	if v, ok := sm.GetByKey(p); ok {
+		return v.(*arc).w
+	}
+	return 0
+}
+
+func keys(sm *skm.SKM) []string {
+	var kk = make([]string, 0, sm.Len())
+	sm.Over(func(i int, n string, v interface{}) bool {
+		kk = append(kk, n)
+		return true
+	})
+	return kk
+}
+
+// wake signals the transition to check incoming places again. Signal is never blocked, because single pending signal
+// is enough to recheck all incoming places
+func (t *T) wake() {
+	select {
+	case t.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// lockset builds a list of all places are checked by the transition, including places of conflicting transitions with
+// a higher priority. Places are sorted by name, so all transitions lock places in the same order. Transition watches
+// places of conflicting transitions to recheck them when their state is changed
+func (t *T) lockset() {
+	var tt = skm.NewSKM()
+	t.ins.Over(func(i int, n string, v interface{}) bool {
+		v.(*arc).p.outs.Over(func(i int, n string, v interface{}) bool {
+			if u := v.(*T); u.priority > t.priority {
+				tt.Add(n, u)
+			}
+			return true
+		})
+		return true
+	})
+	t.over = t.over[:0]
+	tt.Over(func(i int, n string, v interface{}) bool {
+		t.over = append(t.over, v.(*T))
+		return true
+	})
+
+	var pp = skm.NewSKM()
+	for _, u := range append([]*T{t}, t.over...) {
+		for _, sm := range []*skm.SKM{u.ins, u.reads, u.resets, u.inhibitors} {
+			sm.Over(func(i int, n string, v interface{}) bool {
+				p := v.(*arc).p
+				pp.Add(n, p)
+				if u != t {
+					p.watchers.Add(t.name, t)
+				}
+				return true
+			})
+		}
+	}
+	t.locks = t.locks[:0]
+	pp.Over(func(i int, n string, v interface{}) bool {
+		t.locks = append(t.locks, v.(*P))
+		return true
+	})
+}
+
 func (t *T) inslock() {
+	for _, p := range t.locks {
+		p.mu.Lock()
+	}
+}
+
+// insready returns true when each incoming and read place has enough tokens and each inhibitor place has less tokens
+// than the edge weight. It returns false as the second value when some incoming or read place doesn't have enough
+// tokens and it is drained, or some inhibitor place has too many tokens and nobody takes them, so the transition never
+// fires again
+func (t *T) insready() (bool, bool) {
+	var ready, alive = true, true
+	for _, sm := range []*skm.SKM{t.ins, t.reads} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			a := v.(*arc)
+			if len(a.p.tokens) >= a.w {
+				return true
+			}
+			ready = false
+			alive = !a.p.drained
+			return alive
+		})
+		if !alive {
+			break
+		}
+	}
+	if !ready {
+		return ready, alive
+	}
+	t.inhibitors.Over(func(i int, n string, v interface{}) bool {
+		a := v.(*arc)
+		ready = a.p.held() < a.w
+		if !ready {
+			alive = !a.p.drained || a.p.consumers > 0
+		}
+		return ready
+	})
+	return ready, alive
+}
+
+// insreset removes all tokens from reset places. Tokens are grouped by places
+func (t *T) insreset() [][]*M {
+	var mmm = make([][]*M, 0, t.resets.Len())
+	t.resets.Over(func(i int, n string, v interface{}) bool {
+		a := v.(*arc)
+		mm := a.p.take(len(a.p.tokens))
+		atomic.AddUint64(&a.n, uint64(len(mm)))
+		mmm = append(mmm, mm)
+		return true
+	})
+	return mmm
+}
+
+// discard passes tokens removed from reset places to discard handlers and discard places
+func (t *T) discard(mmm [][]*M, put func(*P, *M)) {
+	t.resets.Over(func(i int, n string, v interface{}) bool {
+		a := v.(*arc)
+		for _, m := range mmm[i] {
+			m.passT(t)
+			if a.discard != nil {
+				a.discard(m)
+			}
+			if a.d != nil {
+				put(a.d, m)
+			}
+		}
+		return true
+	})
+}
+
+// enabled returns true when the transition may fire. It returns false as the second value when the transition never
+// fires again. The error is a panic of the guard, it should be reported after places are unlocked. It should be called
+// under places locks
+func (t *T) enabled() (bool, bool, error) {
+	ready, alive := t.insready()
+	if !ready || t.guard == nil {
+		return ready, alive, nil
+	}
+	ok, err := t.bind()
+	if !ok {
+		// Transition never fires again when the guard rejects tokens of drained places
+		ready, alive = false, !t.insdrained()
+	}
+	return ready, alive, err
+}
+
+// overridden returns true when some conflicting transition with a higher priority is enabled. It should be called under
+// places locks
+func (t *T) overridden() bool {
+	for _, u := range t.over {
+		if ready, _, _ := u.enabled(); ready {
+			return true
+		}
+	}
+	return false
+}
+
+// bind looks for tokens accepted by the guard. Tokens of each place are tried in the order of places, so the first
+// acceptable tokens are chosen. It returns false when the guard rejects all combinations of tokens, and the first panic
+// of the guard. It should be called under places locks
+func (t *T) bind() (bool, error) {
+	var (
+		aa  = make([]*arc, 0, t.ins.Len()+t.reads.Len())
+		mm  []*M
+		err error
+	)
+	for _, sm := range []*skm.SKM{t.ins, t.reads} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			aa = append(aa, v.(*arc))
+			return true
+		})
+	}
+	t.binding = t.binding[:0]
+	// choose picks left tokens of the k-th edge starting from the index, then it picks tokens of the next edges
+	var choose func(k, from, left int) bool
+	choose = func(k, from, left int) bool {
+		if left == 0 {
+			if k += 1; k == len(aa) {
+				ok, e := t.accept(mm)
+				if err == nil {
+					err = e
+				}
+				return ok
+			}
+			from, left = 0, aa[k].w
+		}
+		for i := from; i <= len(aa[k].p.tokens)-left; i += 1 {
+			t.binding, mm = append(t.binding, i), append(mm, aa[k].p.tokens[i])
+			if choose(k, i+1, left-1) {
+				return true
+			}
+			t.binding, mm = t.binding[:len(t.binding)-1], mm[:len(mm)-1]
+		}
+		return false
+	}
+	return len(aa) == 0 || choose(0, 0, aa[0].w), err
+}
+
+// insdrained returns true when all incoming and read places are drained
+func (t *T) insdrained() bool {
+	var drained = true
+	for _, sm := range []*skm.SKM{t.ins, t.reads} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			drained = v.(*arc).p.drained
+			return drained
+		})
+		if !drained {
+			break
+		}
+	}
+	return drained
+}
+
+// instake takes tokens from incoming places and reads tokens from read places. Tokens are grouped by places
+func (t *T) instake() []*M {
+	if t.guard != nil {
+		return t.insbound()
+	}
+	var mm = make([]*M, 0, t.ins.Len()+t.reads.Len())
 	t.ins.Over(func(i int, n string, v interface{}) bool {
-		v.(*P).mu.Lock()
+		a := v.(*arc)
+		mm = append(mm, a.p.take(a.w)...)
+		atomic.AddUint64(&a.n, uint64(a.w))
+		return true
+	})
+	t.reads.Over(func(i int, n string, v interface{}) bool {
+		a := v.(*arc)
+		mm = append(mm, a.p.read(a.w)...)
+		atomic.AddUint64(&a.n, uint64(a.w))
 		return true
 	})
+	return mm
 }
 
-func (t *T) insready() bool {
-	var ready bool
+// insbound takes tokens accepted by the guard, and copies of read tokens accepted by the guard. See bind
+func (t *T) insbound() []*M {
+	var (
+		mm = make([]*M, 0, len(t.binding))
+		k  int
+	)
 	t.ins.Over(func(i int, n string, v interface{}) bool {
-		if i > 0 {
-			ready = ready && v.(*P).ready()
-		} else {
-			ready = v.(*P).ready()
+		a := v.(*arc)
+		mm = append(mm, a.p.pick(t.binding[k:k+a.w])...)
+		atomic.AddUint64(&a.n, uint64(a.w))
+		k += a.w
+		return true
+	})
+	t.reads.Over(func(i int, n string, v interface{}) bool {
+		a := v.(*arc)
+		for _, j := range t.binding[k : k+a.w] {
+			mm = append(mm, a.p.tokens[j].copy())
 		}
-		return ready
+		atomic.AddUint64(&a.n, uint64(a.w))
+		k += a.w
+		return true
 	})
-	return ready
+	return mm
 }
 
 func (t *T) insunlock() {
+	for _, p := range t.locks {
+		p.mu.Unlock()
+	}
+}
+
+// insrelease signals incoming and reset places that they have a room for new tokens, and wakes transitions inhibited
+// by these places
+func (t *T) insrelease() {
+	for _, sm := range []*skm.SKM{t.ins, t.resets} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			a := v.(*arc)
+			a.p.release()
+			for _, sm := range []*skm.SKM{a.p.inhibited, a.p.watchers} {
+				sm.Over(func(i int, n string, v interface{}) bool {
+					v.(*T).wake()
+					return true
+				})
+			}
+			return true
+		})
+	}
+}
+
+// insdetach signals incoming, read and reset places that the transition never takes tokens again
+func (t *T) insdetach() {
+	for _, sm := range []*skm.SKM{t.ins, t.reads, t.resets} {
+		sm.Over(func(i int, n string, v interface{}) bool {
+			v.(*arc).p.detach()
+			return true
+		})
+	}
+}
+
+// fire passes tokens to the router or to the transformation. It returns the result of the transformation, which is
+// passed to all outgoing places, or tokens of the router keyed by outgoing place names. When the firing exceeds the
+// timeout, its context is cancelled and ErrFiringTimeout is returned. Result of the timed out firing is dropped
+func (t *T) fire(mm []*M) (*M, map[string]*M, error) {
+	var (
+		m   *M
+		out map[string]*M
+		err error
+	)
+	if t.timeout <= 0 {
+		m, out, err = t.call(context.Background(), mm)
+	} else {
+		m, out, err = t.timed(mm)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.router == nil {
+		if m != nil {
+			m.passT(t)
+		}
+		return m, nil, nil
+	}
+	var passed = make(map[*M]struct{}, len(out))
+	for _, m := range out {
+		if _, ok := passed[m]; m != nil && !ok {
+			m.passT(t)
+			passed[m] = struct{}{}
+		}
+	}
+	return nil, out, nil
+}
+
+// timed calls the router or the transformation with copies of tokens, and waits for the result until the timeout.
+// Firing also times out when it waits for a call slot, which is held by timed out calls
+func (t *T) timed(mm []*M) (*M, map[string]*M, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		m     *M
+		out   map[string]*M
+		err   error
+		done  = make(chan struct{})
+		timer = t.clock.NewTimer(t.timeout)
+	)
+	defer timer.Stop()
+	select {
+	case t.calls <- struct{}{}:
+	case <-timer.C():
+		return nil, nil, ErrFiringTimeout
+	}
+	var cc = make([]*M, len(mm))
+	for i, m := range mm {
+		cc[i] = m.copy()
+	}
+	go func() {
+		defer func() {
+			<-t.calls
+		}()
+		defer close(done)
+		m, out, err = t.call(ctx, cc)
+	}()
+	select {
+	case <-done:
+		return m, out, err
+	case <-timer.C():
+		return nil, nil, ErrFiringTimeout
+	}
+}
+
+// call passes tokens to the router or to the transformation. Context is passed to the fallible transformation with
+// the clock of the net. Panic is recovered and returned as an error
+func (t *T) call(ctx context.Context, mm []*M) (m *M, out map[string]*M, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m, out, err = nil, nil, NewPanicError(r, nil)
+		}
+	}()
+	switch {
+	case t.router != nil:
+		return nil, t.router(mm), nil
+	case t.fallible != nil:
+		m, err = t.fallible(ContextWithClock(ctx, t.clock), mm)
+		return m, nil, err
+	default:
+		return t.transformation(mm), nil, nil
+	}
+}
+
+// accept returns true when the transition has no guard or the guard accepts tokens. Panic in the guard is returned as
+// an error, and tokens are rejected
+func (t *T) accept(mm []*M) (ok bool, err error) {
+	if t.guard == nil {
+		return true, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ok, err = false, NewPanicError(r, nil)
+		}
+	}()
+	return t.guard(mm), nil
+}
+
+// fail attaches the error to consumed tokens and passes them to the timeout place or to the error place. Without such
+// place tokens are passed to the error handler, or they are counted as dropped. Copies of read tokens are not passed
+func (t *T) fail(mm []*M, err error, put func(*P, *M)) {
+	if t.o&optionLog > 0x0 {
+		trace.Log(t.name, "[fail]", "err:", err)
+	}
+	var a = t.errs
+	if t.timeouts != nil && errors.Is(err, ErrFiringTimeout) {
+		a = t.timeouts
+	}
+	var k int
+	t.ins.Over(func(i int, n string, v interface{}) bool {
+		for _, m := range mm[k : k+v.(*arc).w] {
+			m.SetErr(err)
+			m.passT(t)
+			if a == nil && t.handler == nil {
+				atomic.AddUint64(&t.dropped, 1)
+				continue
+			}
+			if a == nil {
+				t.report(m, err)
+				continue
+			}
+			put(a.p, m)
+			atomic.AddUint64(&a.n, 1)
+		}
+		k += v.(*arc).w
+		return true
+	})
+}
+
+// consumed returns a number of tokens the transition consumes by one firing
+func (t *T) consumed() int {
+	var k int
 	t.ins.Over(func(i int, n string, v interface{}) bool {
-		v.(*P).mu.Unlock()
+		k += v.(*arc).w
 		return true
 	})
+	return k
+}
+
+// report passes the error to the error handler, if any
+func (t *T) report(m *M, err error) {
+	if t.handler != nil {
+		t.handler(m, err)
+	}
+}
+
+// start runs the transition goroutine and returns a channel which is closed when it is completed
+func (t *T) start() <-chan struct{} {
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		t.run()
+		t.insdetach()
+	}()
+	return t.done
 }
 
 func (t *T) run() {
@@ -86,44 +715,210 @@ func (t *T) run() {
 		trace.Log(t.name, "[runinng...]")
 		defer trace.Log(t.name, "[running completed]")
 	}
+	var (
+		wg   = &sync.WaitGroup{}
+		sem  = make(chan struct{}, t.concurrency)
+		slot bool
+		last chan struct{}
+	)
 	for {
+		// Firing slot is taken before tokens, so the transition never holds tokens it can't fire
+		if t.concurrency > 1 && !slot {
+			sem <- struct{}{}
+			slot = true
+		}
 		t.inslock()
-		if !t.insready() {
+		ready, alive, err := t.enabled()
+		if ready && t.overridden() {
+			ready = false
+		}
+		if !ready {
 			t.insunlock()
-			runtime.Gosched()
+			if err != nil {
+				t.report(nil, err)
+			}
+			t.since = time.Time{}
+			if !alive {
+				break
+			}
+			<-t.wakeup
 			continue
 		}
-
-		mm := make([]*M, t.ins.Len())
-		var ok bool
-		t.ins.Over(func(i int, n string, v interface{}) bool {
-			mm[i], ok = <-v.(*P).out
-			return ok
-		})
-		if !ok {
+		if wait := t.wait(); wait > 0 {
 			t.insunlock()
-			break
+			if err != nil {
+				t.report(nil, err)
+			}
+			timer := t.clock.NewTimer(wait)
+			select {
+			case <-t.wakeup:
+			case <-timer.C():
+			}
+			timer.Stop()
+			continue
 		}
+		t.since = time.Time{}
+		mm, mmm := t.instake(), t.insreset()
+		t.insunlock()
+		if err != nil {
+			t.report(nil, err)
+		}
+		t.insrelease()
+		t.discard(mmm, t.xfer)
 		if t.o&optionLog > 0x0 {
 			trace.Log(t.name, "[recv]", "len:", len(mm))
 		}
 
-		m := t.transformation(mm)
-		m.passT(t)
+		if t.concurrency <= 1 {
+			m, out, err := t.fire(mm)
+			t.send(mm, m, out, err, t.xfer)
+			continue
+		}
+		// Firing waits for the previous one before sending tokens, when the order is preserved
+		var prev, done = last, make(chan struct{})
+		if t.ordered {
+			last = done
+		}
+		slot = false
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, out, err := t.fire(mm)
+			if prev != nil {
+				<-prev
+			}
+			t.send(mm, m, out, err, t.xfer)
+			close(done)
+			<-sem
+		}()
+	}
+	wg.Wait()
+
+	var pp = skm.NewSKM()
+	t.outs.Over(func(i int, n string, v interface{}) bool {
+		pp.Add(n, v.(*arc).p)
+		return true
+	})
+	t.resets.Over(func(i int, n string, v interface{}) bool {
+		if d := v.(*arc).d; d != nil {
+			pp.Add(d.name, d)
+		}
+		return true
+	})
+	for _, a := range []*arc{t.errs, t.timeouts} {
+		if a != nil {
+			pp.Add(a.p.name, a.p)
+		}
+	}
+	pp.Over(func(i int, n string, v interface{}) bool {
+		in, _ := v.(*P).ins.GetByKey(t.Name())
+		close(in.(chan *M))
+		return true
+	})
+}
 
+// wait returns a duration the enabled transition should wait before it fires
+func (t *T) wait() time.Duration {
+	if t.delay <= 0 {
+		return 0
+	}
+	var now = t.clock.Now()
+	if t.since.IsZero() {
+		t.since = now
+	}
+	return t.since.Add(t.delay).Sub(now)
+}
+
+// xfer passes the token to the incoming channel of the place. Channel is read by the place goroutine
+func (t *T) xfer(p *P, m *M) {
+	in, _ := p.ins.GetByKey(t.name)
+	in.(chan *M) <- m
+}
+
+// send passes tokens returned by the firing to outgoing places by the put function. Consumed tokens are failed when
+// the firing returns an error
+func (t *T) send(mm []*M, m *M, out map[string]*M, err error, put func(*P, *M)) {
+	if err != nil {
+		t.fail(mm, err, put)
+		return
+	}
+	if t.router == nil {
+		t.broadcast(m, put)
+		return
+	}
+	// Token which is passed several times is cloned, so each branch gets its own token
+	var nn map[*M]int
+	if t.shared() {
+		nn = make(map[*M]int, len(out))
 		t.outs.Over(func(i int, n string, v interface{}) bool {
-			in, _ := v.(*P).ins.GetByKey(t.Name())
-			in.(chan *M) <- m
+			if m := out[n]; m != nil {
+				nn[m] += v.(*arc).w
+			}
 			return true
 		})
+	}
+	var sent bool
+	t.outs.Over(func(i int, n string, v interface{}) bool {
+		m, ok := out[n]
+		if !ok || m == nil {
+			return true
+		}
+		sent = true
+		a := v.(*arc)
+		for k := 0; k < a.w; k += 1 {
+			if nn[m] > 1 {
+				put(a.p, m.clone())
+			} else {
+				put(a.p, m)
+			}
+		}
+		atomic.AddUint64(&a.n, uint64(a.w))
 		if t.o&optionLog > 0x0 {
-			trace.Log(t.name, "[xfer]", "v:", m.Value())
+			trace.Log(t.name, "[xfer]", "n:", n, "v:", m.Value())
 		}
+		return true
+	})
+	// Tokens consumed by the firing are dropped, when the router passes nothing to outgoing places
+	if !sent && t.outs.Len() > 0 {
+		atomic.AddUint64(&t.dropped, uint64(t.consumed()))
 	}
+}
 
+// broadcast passes the result of the transformation to all outgoing places by the put function. Token which is passed
+// several times is cloned, so each branch gets its own token. Tokens consumed by the firing without a result are
+// counted as dropped
+func (t *T) broadcast(m *M, put func(*P, *M)) {
+	if m == nil {
+		if t.outs.Len() > 0 {
+			atomic.AddUint64(&t.dropped, uint64(t.consumed()))
+		}
+		return
+	}
+	var shared = t.shared()
 	t.outs.Over(func(i int, n string, v interface{}) bool {
-		in, _ := v.(*P).ins.GetByKey(t.Name())
-		close(in.(chan *M))
+		a := v.(*arc)
+		for k := 0; k < a.w; k += 1 {
+			if shared {
+				put(a.p, m.clone())
+			} else {
+				put(a.p, m)
+			}
+		}
+		atomic.AddUint64(&a.n, uint64(a.w))
+		if t.o&optionLog > 0x0 {
+			trace.Log(t.name, "[xfer]", "n:", n, "v:", m.Value())
+		}
 		return true
 	})
 }
+
+// shared returns true when the transition passes more than one token by one firing, so a token may be shared by
+// several outgoing places
+func (t *T) shared() bool {
+	var k int
+	t.outs.Over(func(i int, n string, v interface{}) bool {
+		k += v.(*arc).w
+		return k <= 1
+	})
+	return k > 1
+}
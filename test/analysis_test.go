@@ -0,0 +1,397 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/analysis"
+)
+
+type AnalysisSuite struct{}
+
+var _ = Suite(&AnalysisSuite{})
+
+func (s *AnalysisSuite) TestIncidence(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1").
+		PT("p2", "t1").
+		TP("t1", "p3")
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Places, DeepEquals, []string{"p1", "p2", "p3"})
+	c.Assert(net.Transitions, DeepEquals, []string{"t1"})
+	c.Assert(net.Incidence(), DeepEquals, [][]int{{-1}, {-1}, {1}})
+}
+
+func (s *AnalysisSuite) TestBounded(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1").
+		TP("t1", "p2").
+		PT("p2", "t2").
+		TP("t2", "p1")
+
+	g, err := analysis.NewNet(n).Reachability(analysis.Marking{"p1": 1}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.Nodes, HasLen, 2)
+	c.Assert(g.Bounded(), Equals, true)
+	c.Assert(g.Bound("p1"), Equals, 1)
+	c.Assert(g.DeadlockFree(), Equals, true)
+
+	seq, ok, err := g.Reachable(analysis.Marking{"p2": 1})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"t1"})
+
+	_, ok, err = g.Reachable(analysis.Marking{"p1": 1, "p2": 1})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *AnalysisSuite) TestUnbounded(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p", "t").
+		TP("t", "p").
+		TP("t", "q")
+
+	g, err := analysis.NewNet(n).Reachability(analysis.Marking{"p": 1}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.Bounded(), Equals, false)
+	c.Assert(g.Bound("p"), Equals, 1)
+	c.Assert(g.Bound("q"), Equals, analysis.Omega)
+	c.Assert(g.Nodes[1].Marking.String(), Equals, "{p:1 q:ω}")
+
+	_, _, err = g.Reachable(analysis.Marking{"q": 5})
+	c.Assert(err, Equals, analysis.ErrUnbounded)
+
+	seq, ok, err := g.Coverable(analysis.Marking{"p": 1, "q": 5})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"t"})
+}
+
+func (s *AnalysisSuite) TestDeadlocks(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("pin", "t1").
+		PT("pin", "t2").
+		TP("t1", "pout").
+		PT("pout", "t3").
+		TP("t3", "done")
+
+	g, err := analysis.NewNet(n).Reachability(analysis.Marking{"pin": 1}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.DeadlockFree(), Equals, false)
+	c.Assert(g.Deadlocks(), DeepEquals, [][]string{{"t2"}, {"t1", "t3"}})
+
+	_, err = analysis.NewNet(n).Reachability(analysis.Marking{"pin": 1}, 2)
+	c.Assert(err, Equals, analysis.ErrTooLarge)
+
+	_, err = analysis.NewNet(n).Reachability(analysis.Marking{"unknown": 1}, 0)
+	c.Assert(err, ErrorMatches, `analysis: unknown place "unknown"`)
+}
+
+func (s *AnalysisSuite) TestInvariants(c *C) {
+	// Requests are accepted while there are free workers and responded later, so the number of free workers and
+	// in-flight requests is conserved
+	n := cpn.NewPN()
+	n.
+		PT("req", "accept").
+		PT("free", "accept").
+		TP("accept", "busy").
+		PT("busy", "respond").
+		TP("respond", "free").
+		TP("respond", "res").
+		PT("res", "recycle").
+		TP("recycle", "req")
+
+	net := analysis.NewNet(n)
+	pp := net.PInvariants()
+	c.Assert(pp, DeepEquals, []analysis.Invariant{
+		{"busy": 1, "free": 1},
+		{"busy": 1, "req": 1, "res": 1},
+	})
+	m0 := analysis.Marking{"req": 3, "free": 2}
+	c.Assert(pp[0].Value(m0), Equals, 2)
+
+	g, err := net.Reachability(m0, 0)
+	c.Assert(err, IsNil)
+	for _, nd := range g.Nodes {
+		c.Assert(pp[0].Value(nd.Marking), Equals, 2)
+		c.Assert(pp[1].Value(nd.Marking), Equals, 3)
+	}
+
+	c.Assert(net.TInvariants(), DeepEquals, []analysis.Invariant{
+		{"accept": 1, "recycle": 1, "respond": 1},
+	})
+}
+
+func (s *AnalysisSuite) TestWorkflowSound(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("i", "split").
+		TP("split", "a1").
+		TP("split", "b1").
+		PT("a1", "ta").
+		TP("ta", "a2").
+		PT("b1", "tb").
+		TP("tb", "b2").
+		PT("a2", "join").
+		PT("b2", "join").
+		TP("join", "o")
+	c.Assert(analysis.CheckWorkflowSoundness(n), IsNil)
+}
+
+func (s *AnalysisSuite) TestWorkflowUnsound(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("i", "t1").
+		TP("t1", "a").
+		TP("t1", "b").
+		PT("a", "t2").
+		TP("t2", "o").
+		PT("b", "t3").
+		TP("t3", "o")
+
+	err := analysis.CheckWorkflowSoundness(n)
+	c.Assert(err, NotNil)
+	serr := err.(*analysis.SoundnessError)
+	c.Assert(serr.Violations, HasLen, 2)
+	c.Assert(serr.Violations[0].Err, Equals, analysis.ErrImproperCompletion)
+	c.Assert(serr.Violations[0].Sequence, DeepEquals, []string{"t1", "t2"})
+	c.Assert(serr.Violations[1].Err, Equals, analysis.ErrNoOptionToComplete)
+	c.Assert(serr.Violations[1].Sequence, IsNil)
+	c.Assert(err, ErrorMatches, `analysis: improper completion: marking \{b:1 o:1\} after \[t1 t2\]; `+
+		`analysis: no option to complete: marking \{i:1\}`)
+}
+
+func (s *AnalysisSuite) TestWorkflowDeadTransition(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("i", "t1").
+		TP("t1", "p1").
+		PT("p1", "t2").
+		TP("t2", "o").
+		PT("p1", "t3").
+		PT("p3", "t3").
+		TP("t3", "p3").
+		TP("t3", "o")
+
+	err := analysis.CheckWorkflowSoundness(n)
+	c.Assert(err, NotNil)
+	serr := err.(*analysis.SoundnessError)
+	c.Assert(serr.Violations, HasLen, 1)
+	c.Assert(errors.Is(serr.Violations[0], analysis.ErrDeadTransition), Equals, true)
+	c.Assert(serr.Violations[0].Transition, Equals, "t3")
+}
+
+func (s *AnalysisSuite) TestWorkflowErrorPlaces(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("i", "t1").
+		TP("t1", "p1").
+		TPError("t1", "perror").
+		TPTimeout("t1", "ptimeout").
+		PT("p1", "t2").
+		PTReset("i", "t2", cpn.WithDiscardPlace("pdiscard")).
+		TP("t2", "o")
+	c.Assert(analysis.CheckWorkflowSoundness(n), IsNil)
+
+	// Error place which passes tokens further is a part of the workflow
+	n.
+		PT("perror", "t3").
+		TP("t3", "o")
+	err := analysis.CheckWorkflowSoundness(n)
+	c.Assert(err, ErrorMatches, `analysis: not a workflow net place "perror": not on a path from "i" to "o"; `+
+		`analysis: not a workflow net "t3": not on a path from "i" to "o"`)
+}
+
+func (s *AnalysisSuite) TestNotWorkflow(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("i1", "t1").
+		PT("i2", "t1").
+		TP("t1", "o")
+
+	err := analysis.CheckWorkflowSoundness(n)
+	c.Assert(err, ErrorMatches, `analysis: not a workflow net: initial places \[i1 i2\], terminal places \[o\]`)
+}
+
+func (s *AnalysisSuite) TestWeights(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1", cpn.WithWeight(2)).
+		TP("t1", "p2", cpn.WithWeight(3))
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Incidence(), DeepEquals, [][]int{{-2}, {3}})
+	c.Assert(net.PInvariants(), DeepEquals, []analysis.Invariant{{"p1": 3, "p2": 2}})
+
+	g, err := net.Reachability(analysis.Marking{"p1": 5}, 0)
+	c.Assert(err, IsNil)
+	seq, ok, err := g.Reachable(analysis.Marking{"p1": 1, "p2": 6})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"t1", "t1"})
+}
+
+func (s *AnalysisSuite) TestInhibitors(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1").
+		PTInhibitor("p2", "t1").
+		TP("t1", "p2").
+		PT("p2", "t2").
+		TP("t2", "p3")
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Inhibitors, DeepEquals, [][]int{{0, 1, 0}, {0, 0, 0}})
+
+	g, err := net.Reachability(analysis.Marking{"p1": 3}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.Bound("p2"), Equals, 1)
+	seq, ok, err := g.Reachable(analysis.Marking{"p3": 3})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"t1", "t2", "t1", "t2", "t1", "t2"})
+}
+
+func (s *AnalysisSuite) TestReads(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1").
+		PTRead("flag", "t1").
+		TP("t1", "p2")
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Incidence(), DeepEquals, [][]int{{0}, {-1}, {1}})
+
+	g, err := net.Reachability(analysis.Marking{"p1": 2}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.Nodes, HasLen, 1)
+
+	g, err = net.Reachability(analysis.Marking{"p1": 2, "flag": 1}, 0)
+	c.Assert(err, IsNil)
+	seq, ok, err := g.Reachable(analysis.Marking{"p2": 2, "flag": 1})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"t1", "t1"})
+}
+
+func (s *AnalysisSuite) TestResets(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("job", "cancel").
+		PTReset("retry", "cancel", cpn.WithDiscardPlace("discarded")).
+		TP("cancel", "cancelled")
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Places, DeepEquals, []string{"cancelled", "discarded", "job", "retry"})
+	c.Assert(net.Resets, DeepEquals, [][]bool{{false, false, false, true}})
+
+	g, err := net.Reachability(analysis.Marking{"job": 1, "retry": 3}, 0)
+	c.Assert(err, IsNil)
+	seq, ok, err := g.Reachable(analysis.Marking{"cancelled": 1, "discarded": 3})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, DeepEquals, []string{"cancel"})
+}
+
+func (s *AnalysisSuite) TestPriorities(c *C) {
+	n := cpn.NewPN()
+	n.T("t1", cpn.WithPriority(1))
+	n.
+		PT("p", "t1").
+		TP("t1", "q1").
+		PT("p", "t2").
+		TP("t2", "q2")
+
+	net := analysis.NewNet(n)
+	c.Assert(net.Priorities, DeepEquals, []int{1, 0})
+
+	g, err := net.Reachability(analysis.Marking{"p": 1}, 0)
+	c.Assert(err, IsNil)
+	c.Assert(g.Nodes, HasLen, 2)
+	_, ok, err := g.Reachable(analysis.Marking{"q2": 1})
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *AnalysisSuite) TestSimulateQueue(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("source", "arrive").
+		TP("arrive", "source").
+		TP("arrive", "queue").
+		PT("queue", "serve").
+		TP("serve", "sink")
+
+	st, err := analysis.NewNet(n).Simulate(analysis.Marking{"source": 1},
+		analysis.WithTimed("arrive", analysis.Exponential(time.Second)),
+		analysis.WithTimed("serve", analysis.Exponential(time.Second/2)),
+		analysis.WithFirings(200000),
+		analysis.WithSeed(7),
+	)
+	c.Assert(err, IsNil)
+	c.Assert(st.Deadlock, Equals, false)
+	c.Assert(st.Firings["arrive"]+st.Firings["serve"], Equals, 200000)
+	// M/M/1 queue with utilization 0.5 keeps one token and holds it for one second on average
+	c.Assert(math.Abs(st.Throughput["arrive"]-1) < 0.05, Equals, true)
+	c.Assert(math.Abs(st.Tokens["queue"]-1) < 0.1, Equals, true)
+	c.Assert(math.Abs(st.Sojourn["queue"].Seconds()-1) < 0.1, Equals, true)
+	c.Assert(st.Tokens["source"], Equals, 1.0)
+}
+
+func (s *AnalysisSuite) TestSimulateImmediate(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("p1", "t1").
+		PT("p1", "t2").
+		TP("t1", "p2").
+		TP("t2", "p2").
+		PT("p2", "t3").
+		TP("t3", "p1")
+
+	st, err := analysis.NewNet(n).Simulate(analysis.Marking{"p1": 1},
+		analysis.WithImmediate("t1", 3),
+		analysis.WithTimed("t3", analysis.Deterministic(time.Second)),
+		analysis.WithFirings(8000),
+	)
+	c.Assert(err, IsNil)
+	c.Assert(st.Time, Equals, 4000*time.Second)
+	c.Assert(st.Throughput["t3"], Equals, 1.0)
+	c.Assert(st.Firings["t1"]+st.Firings["t2"], Equals, 4000)
+	c.Assert(math.Abs(float64(st.Firings["t1"])/float64(st.Firings["t2"])-3) < 0.3, Equals, true)
+	c.Assert(st.Tokens, DeepEquals, map[string]float64{"p1": 0, "p2": 1})
+	c.Assert(st.Sojourn, DeepEquals, map[string]time.Duration{"p1": 0, "p2": time.Second})
+
+	st, err = analysis.NewNet(n).Simulate(analysis.Marking{"p2": 1},
+		analysis.WithTimed("t1", analysis.Deterministic(time.Second)),
+		analysis.WithTimed("t3", analysis.Deterministic(time.Second)),
+		analysis.WithFirings(10),
+	)
+	c.Assert(err, IsNil)
+	c.Assert(st.Deadlock, Equals, false)
+	c.Assert(st.Firings, DeepEquals, map[string]int{"t1": 0, "t2": 5, "t3": 5})
+
+	_, err = analysis.NewNet(n).Simulate(analysis.Marking{"p1": 1}, analysis.WithImmediate("t4", 1))
+	c.Assert(err, ErrorMatches, `analysis: unknown transition "t4"`)
+}
+
+func (s *AnalysisSuite) TestSimulateDeadlock(c *C) {
+	n := cpn.NewPN()
+	n.PT("p1", "t1").TP("t1", "p2")
+
+	st, err := analysis.NewNet(n).Simulate(analysis.Marking{"p1": 2},
+		analysis.WithTimed("t1", analysis.Uniform(time.Second, 2*time.Second)))
+	c.Assert(err, IsNil)
+	c.Assert(st.Deadlock, Equals, true)
+	c.Assert(st.Firings["t1"], Equals, 2)
+	c.Assert(st.Time >= 2*time.Second && st.Time <= 4*time.Second, Equals, true)
+}
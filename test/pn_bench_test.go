@@ -29,8 +29,10 @@ func BenchmarkBlockPTP(b *testing.B) {
 
 	n.
 		PT("pin", "t1").
-		TP("t1", "pout").
-		Run()
+		TP("t1", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("pin").In() <- mm[i]
@@ -65,8 +67,10 @@ func BenchmarkBlockPTPTP(b *testing.B) {
 		PT("pin", "t1").
 		TP("t1", "p1").
 		PT("p1", "t2").
-		TP("t2", "pout").
-		Run()
+		TP("t2", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("pin").In() <- mm[i]
@@ -108,8 +112,10 @@ func BenchmarkBlockPTPTPTP(b *testing.B) {
 		PT("p1", "t2").
 		TP("t2", "p2").
 		PT("p2", "t3").
-		TP("t3", "pout").
-		Run()
+		TP("t3", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("pin").In() <- mm[i]
@@ -143,8 +149,10 @@ func BenchmarkQueuePTP(b *testing.B) {
 
 	n.
 		PT("pin", "t1").
-		TP("t1", "pout").
-		Run()
+		TP("t1", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("pin").In() <- mm[i]
@@ -178,8 +186,10 @@ func BenchmarkPTPP(b *testing.B) {
 	n.
 		PT("pin", "t").
 		TP("t", "pout1").
-		TP("t", "pout2").
-		Run()
+		TP("t", "pout2")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("pin").In() <- mm[i]
@@ -213,8 +223,10 @@ func BenchmarkPPTP(b *testing.B) {
 	n.
 		PT("p1", "t1").
 		PT("p2", "t1").
-		TP("t1", "pout").
-		Run()
+		TP("t1", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("p1").In() <- mm[i]
@@ -252,8 +264,10 @@ func BenchmarkPPTTP(b *testing.B) {
 		PT("p1", "t2").
 		PT("p2", "t2").
 		TP("t1", "pout").
-		TP("t2", "pout").
-		Run()
+		TP("t2", "pout")
+	if err := n.Run(); err != nil {
+		b.Fatal(err)
+	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i += 1 {
 		n.P("p1").In() <- mm[i]
@@ -6,11 +6,16 @@ import (
 
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/cpntest"
 	"github.com/alxmsl/cpn/place/io"
 	"github.com/alxmsl/cpn/place/memory"
 	"github.com/alxmsl/cpn/transition"
@@ -38,10 +43,10 @@ func (s *PNSuite) TestPTP(c *C) {
 		cpn.WithStrategy(memory.NewBlock()),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("pin", "t1").
 		TP("t1", "pout").
-		Run()
+		Run(), IsNil)
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("pin").In() <- cpn.NewM(i)
@@ -88,12 +93,12 @@ func (s *PNSuite) TestPTTP(c *C) {
 		cpn.WithStrategy(memory.NewBlock()),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("pin", "t1").
 		PT("pin", "t2").
 		TP("t1", "pout").
 		TP("t2", "pout").
-		Run()
+		Run(), IsNil)
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("pin").In() <- cpn.NewM(i)
@@ -143,11 +148,11 @@ func (s *PNSuite) TestPTPP(c *C) {
 		cpn.WithStrategy(memory.NewBlock()),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("pin", "t").
 		TP("t", "pout1").
 		TP("t", "pout2").
-		Run()
+		Run(), IsNil)
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("pin").In() <- cpn.NewM(i)
@@ -200,11 +205,11 @@ func (s *PNSuite) TestPPTP(c *C) {
 		cpn.WithStrategy(memory.NewBlock()),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("p1", "t1").
 		PT("p2", "t1").
 		TP("t1", "pout").
-		Run()
+		Run(), IsNil)
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("p1").In() <- cpn.NewM(i)
@@ -259,14 +264,14 @@ func (s *PNSuite) TestPPTTP(c *C) {
 		cpn.WithStrategy(memory.NewBlock()),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("p1", "t1").
 		PT("p2", "t1").
 		PT("p1", "t2").
 		PT("p2", "t2").
 		TP("t1", "pout").
 		TP("t2", "pout").
-		Run()
+		Run(), IsNil)
 	go func() {
 		for i := 0; i < 1000; i += 1 {
 			n.P("p1").In() <- cpn.NewM(i)
@@ -325,7 +330,7 @@ func (s *PNSuite) TestPrintNet(c *C) {
 		}
 		cancel()
 	}()
-	n.RunSync()
+	c.Assert(n.RunSync(), IsNil)
 	c.Assert(w.String(), Equals, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
 }
 
@@ -368,7 +373,7 @@ func (s *PNSuite) TestMultipleValues(c *C) {
 		n.P("p1").In() <- m
 		n.P("p1").Close()
 	}()
-	n.RunSync()
+	c.Assert(n.RunSync(), IsNil)
 
 	// The backward compatibility check
 	c.Assert(m.Value(), NotNil)
@@ -383,3 +388,1259 @@ func (s *PNSuite) TestMultipleValues(c *C) {
 	c.Assert(m.ValueByPlace("p3", 0), NotNil)
 	c.Assert(m.ValueByPlace("p3", 0).(string), Equals, "value from p3")
 }
+
+func (s *PNSuite) TestIdleTransitionBlocks(c *C) {
+	var (
+		rejected, accepted int64
+		accept             int32
+		checks             = make(chan struct{}, 1)
+	)
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			defer func() {
+				select {
+				case checks <- struct{}{}:
+				default:
+				}
+			}()
+			if atomic.LoadInt32(&accept) > 0 {
+				atomic.AddInt64(&accepted, 1)
+				return true
+			}
+			atomic.AddInt64(&rejected, 1)
+			return false
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(1))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	// Transition checks the rejected token when it is passed, then it sleeps until the place state is changed. The
+	// place is changed again only when it is closed by the shutdown, and the token is accepted once then. The token is
+	// rejected twice at most, when it is passed before the first check and the wakeup of the passing is still pending
+	n.P("pin").Send(cpn.NewM(1))
+	<-checks
+	held(c, n, "pin", 1)
+	atomic.StoreInt32(&accept, 1)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+	c.Assert(atomic.LoadInt64(&accepted), Equals, int64(1))
+	c.Assert(atomic.LoadInt64(&rejected) <= 2, Equals, true, Commentf("rejected: %d", atomic.LoadInt64(&rejected)))
+}
+
+func (s *PNSuite) TestShutdown(c *C) {
+	w := bytes.NewBufferString("")
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(io.NewWriter(io.WriterOption(w))),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+	for i := 0; i < 10; i += 1 {
+		n.P("pin").In() <- cpn.NewM(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c.Assert(n.Shutdown(ctx), IsNil)
+	c.Assert(w.String(), Equals, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+}
+
+func (s *PNSuite) TestShutdownDeadline(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+	// Nobody reads the terminal place, so the token is stuck in the place
+	n.P("pin").In() <- cpn.NewM(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := n.Shutdown(ctx)
+	c.Assert(err, NotNil)
+	serr, ok := err.(*cpn.ShutdownError)
+	c.Assert(ok, Equals, true)
+	c.Assert(serr.Err, Equals, context.DeadlineExceeded)
+	c.Assert(serr.Places, DeepEquals, []string{"pout"})
+	c.Assert(serr.Transitions, HasLen, 0)
+}
+
+func (s *PNSuite) TestShutdownStranded(c *C) {
+	n := cpn.NewPN()
+	for _, name := range []string{"p1", "p2", "pout"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		)
+	}
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.T("t2", cpn.WithTransformation(func([]*cpn.M) *cpn.M {
+		return nil
+	}))
+	c.Assert(n.
+		PT("p1", "t1", cpn.WithWeight(2)).
+		TP("t1", "pout").
+		PT("p2", "t2").
+		TP("t2", "pout").
+		Run(), IsNil)
+
+	n.P("p1").Send(cpn.NewM(1))
+	n.P("p2").Send(cpn.NewM(2))
+	err := n.Shutdown(context.Background())
+	c.Assert(err, ErrorMatches, `shutdown: stranded tokens: left: \[p1:1\], dropped: \[t2:1\]`)
+	serr, ok := err.(*cpn.ShutdownError)
+	c.Assert(ok, Equals, true)
+	c.Assert(serr.Stranded, DeepEquals, map[string]int{"p1": 1})
+	c.Assert(serr.Dropped, DeepEquals, map[string]int{"t2": 1})
+}
+
+func (s *PNSuite) TestValidate(c *C) {
+	n := cpn.NewPN()
+	n.P("pin", cpn.WithStrategy(memory.NewBlock()))
+	n.T("t1")
+	n.T("t2", cpn.WithTransformation(transition.First))
+	n.P("pout", cpn.WithContext(context.Background()))
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TP("t2", "pout")
+
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	verr, ok := err.(*cpn.ValidationError)
+	c.Assert(ok, Equals, true)
+	c.Assert(verr.Errs, HasLen, 4)
+	c.Assert(errors.Is(verr.Errs[0], cpn.ErrNoContext), Equals, true)
+	c.Assert(verr.Errs[0].(*cpn.StructureError).Name, Equals, "pin")
+	c.Assert(errors.Is(verr.Errs[1], cpn.ErrNoStrategy), Equals, true)
+	c.Assert(verr.Errs[1].(*cpn.StructureError).Name, Equals, "pout")
+	c.Assert(errors.Is(verr.Errs[2], cpn.ErrNoTransformation), Equals, true)
+	c.Assert(verr.Errs[2].(*cpn.StructureError).Name, Equals, "t1")
+	c.Assert(errors.Is(verr.Errs[3], cpn.ErrNoInputs), Equals, true)
+	c.Assert(verr.Errs[3].(*cpn.StructureError).Name, Equals, "t2")
+
+	c.Assert(n.Run(), ErrorMatches, "validation: place \"pin\": no context; .*")
+}
+
+func (s *PNSuite) TestWriteDOT(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+		cpn.WithKeep(true),
+	)
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout")
+
+	w := bytes.NewBufferString("")
+	c.Assert(n.WriteDOT(w), IsNil)
+	c.Assert(w.String(), Equals, `digraph PN {
+	rankdir=LR;
+	"p:pin" [shape=circle, label="pin", style=bold];
+	"p:pout" [shape=circle, label="pout", peripheries=2];
+	"t:t1" [shape=box, style=filled, fillcolor=black, width=0.1, height=0.5, label="", xlabel="t1"];
+	"p:pin" -> "t:t1";
+	"t:t1" -> "p:pout";
+}
+`)
+
+	c.Assert(n.Run(), IsNil)
+	n.P("pin").In() <- cpn.NewM(0)
+	<-n.P("pout").Out()
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+
+	w.Reset()
+	c.Assert(n.WriteDOT(w, cpn.WithTokenCounts(true)), IsNil)
+	c.Assert(w.String(), Equals, `digraph PN {
+	rankdir=LR;
+	"p:pin" [shape=circle, label="pin\n0", style=bold];
+	"p:pout" [shape=circle, label="pout\n1", peripheries=2];
+	"t:t1" [shape=box, style=filled, fillcolor=black, width=0.1, height=0.5, label="", xlabel="t1"];
+	"p:pin" -> "t:t1" [label="1"];
+	"t:t1" -> "p:pout" [label="1"];
+}
+`)
+
+	// Initial place shows tokens which are not consumed yet
+	n = conflict()
+	st, err := cpn.NewStepper(n)
+	c.Assert(err, IsNil)
+	c.Assert(st.Put("pin", cpn.NewM(0)), IsNil)
+	c.Assert(st.Put("pin", cpn.NewM(1)), IsNil)
+	_, err = st.Step()
+	c.Assert(err, IsNil)
+	w.Reset()
+	c.Assert(n.WriteDOT(w, cpn.WithTokenCounts(true)), IsNil)
+	c.Assert(w.String(), Equals, `digraph PN {
+	rankdir=LR;
+	"p:p1" [shape=circle, label="p1\n1", peripheries=2];
+	"p:p2" [shape=circle, label="p2\n0", peripheries=2];
+	"p:pin" [shape=circle, label="pin\n1", style=bold];
+	"t:t1" [shape=box, style=filled, fillcolor=black, width=0.1, height=0.5, label="", xlabel="t1"];
+	"t:t2" [shape=box, style=filled, fillcolor=black, width=0.1, height=0.5, label="", xlabel="t2"];
+	"p:pin" -> "t:t1" [label="1"];
+	"t:t1" -> "p:p1" [label="1"];
+	"p:pin" -> "t:t2" [label="0"];
+	"t:t2" -> "p:p2" [label="0"];
+}
+`)
+}
+
+func (s *PNSuite) TestWeights(c *C) {
+	n := cpn.NewPN()
+	n.P("p1",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.P("p2",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1", cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+		// Tokens are grouped by incoming places: three tokens from `p1`, then one token from `p2`
+		c.Assert(mm, HasLen, 4)
+		for _, m := range mm[:3] {
+			c.Assert(m.Path()[0].N, Equals, "p1")
+		}
+		c.Assert(mm[3].Path()[0].N, Equals, "p2")
+		return cpn.NewM(mm[0].Value().(int) + mm[1].Value().(int) + mm[2].Value().(int) + mm[3].Value().(int))
+	}))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("p1", "t1", cpn.WithWeight(3)).
+		PT("p2", "t1").
+		TP("t1", "pout", cpn.WithWeight(2)).
+		Run(), IsNil)
+	c.Assert(n.T("t1").InWeight("p1"), Equals, 3)
+	c.Assert(n.T("t1").InWeight("p2"), Equals, 1)
+	c.Assert(n.T("t1").OutWeight("pout"), Equals, 2)
+
+	for i := 0; i < 6; i += 1 {
+		n.P("p1").In() <- cpn.NewM(i)
+	}
+	n.P("p2").In() <- cpn.NewM(100)
+	n.P("p2").In() <- cpn.NewM(200)
+
+	for _, v := range []int{103, 103, 212, 212} {
+		m := <-n.P("pout").Out()
+		c.Assert(m.Value(), Equals, v)
+		c.Assert(m.Word(), DeepEquals, []string{"t1"})
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestInvalidWeight(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.PT("pin", "t1", cpn.WithWeight(0))
+
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err.(*cpn.ValidationError).Errs[0], cpn.ErrInvalidWeight), Equals, true)
+	c.Assert(err, ErrorMatches, `validation: transition "t1": place "pin": invalid weight`)
+}
+
+func (s *PNSuite) TestInhibitor(c *C) {
+	n := cpn.NewPN()
+	for _, name := range []string{"pin", "pstop", "pgo"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		)
+	}
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.T("t2", cpn.WithTransformation(transition.First))
+	for _, name := range []string{"pout", "pdone"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	n.
+		PT("pin", "t1").
+		PTInhibitor("pstop", "t1").
+		TP("t1", "pout").
+		PT("pgo", "t2").
+		PT("pstop", "t2").
+		TP("t2", "pdone")
+	c.Assert(n.T("t1").Inhibitors(), DeepEquals, []string{"pstop"})
+	c.Assert(n.T("t1").InhibitorWeight("pstop"), Equals, 1)
+	c.Assert(n.P("pstop").Outs(), DeepEquals, []string{"t2"})
+
+	w := bytes.NewBufferString("")
+	c.Assert(n.WriteDOT(w), IsNil)
+	c.Assert(w.String(), Matches, `(?s).*"p:pstop" -> "t:t1" \[arrowhead=odot\];.*`)
+
+	c.Assert(n.Run(), IsNil)
+	n.P("pstop").Send(cpn.NewM("stop"))
+	held(c, n, "pstop", 1)
+	n.P("pin").Send(cpn.NewM(1))
+	select {
+	case <-n.P("pout").Out():
+		c.Fatal("inhibited transition fired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	n.P("pgo").Send(cpn.NewM("go"))
+	c.Assert((<-n.P("pdone").Out()).Value(), Equals, "go")
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestInhibitorSink(c *C) {
+	n := cpn.NewPN()
+	for _, name := range []string{"pin", "pstop"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		)
+	}
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.
+		PT("pin", "t1").
+		PTInhibitor("pstop", "t1").
+		TP("t1", "pout")
+	c.Assert(n.P("pstop").Terminal(), Equals, false)
+
+	c.Assert(n.Run(), IsNil)
+	n.P("pin").In() <- cpn.NewM(1)
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+	held(c, n, "pin", 0)
+
+	n.P("pstop").In() <- cpn.NewM("stop")
+	held(c, n, "pstop", 1)
+	n.P("pin").In() <- cpn.NewM(2)
+	held(c, n, "pin", 1)
+	select {
+	case <-n.P("pout").Out():
+		c.Fatal("inhibited transition fired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	err := n.Shutdown(context.Background())
+	c.Assert(errors.Is(err, cpn.ErrStrandedTokens), Equals, true)
+	c.Assert(err.(*cpn.ShutdownError).Stranded, DeepEquals, map[string]int{"pin": 1})
+}
+
+// held waits until the place holds n tokens, as they are shown by DOT
+func held(c *C, pn *cpn.PN, p string, n int) {
+	label := fmt.Sprintf("label=%q", fmt.Sprintf("%s\n%d", p, n))
+	for {
+		w := bytes.NewBufferString("")
+		c.Assert(pn.WriteDOT(w, cpn.WithTokenCounts(true)), IsNil)
+		if strings.Contains(w.String(), label) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *PNSuite) TestInvalidInhibitorWeight(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.PT("pin", "t1").PTInhibitor("pin", "t1", cpn.WithWeight(0))
+
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err.(*cpn.ValidationError).Errs[0], cpn.ErrInvalidWeight), Equals, true)
+}
+
+func (s *PNSuite) TestRead(c *C) {
+	n := cpn.NewPN()
+	for _, name := range []string{"pin", "pflag"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		)
+	}
+	n.T("t1", cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+		// Consumed token from `pin` goes first, then the read token from `pflag`
+		c.Assert(mm, HasLen, 2)
+		return cpn.NewM(mm[0].Value().(int) * mm[1].Value().(int))
+	}))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	n.
+		PT("pin", "t1").
+		PTRead("pflag", "t1").
+		TP("t1", "pout")
+	c.Assert(n.T("t1").Reads(), DeepEquals, []string{"pflag"})
+	c.Assert(n.T("t1").ReadWeight("pflag"), Equals, 1)
+	c.Assert(n.P("pflag").Terminal(), Equals, false)
+
+	w := bytes.NewBufferString("")
+	c.Assert(n.WriteDOT(w), IsNil)
+	c.Assert(w.String(), Matches, `(?s).*"p:pflag" -> "t:t1" \[dir=none\];.*`)
+
+	c.Assert(n.Run(), IsNil)
+	for i := 1; i <= 3; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+	}
+	select {
+	case <-n.P("pout").Out():
+		c.Fatal("transition fired without the read token")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	n.P("pflag").Send(cpn.NewM(10))
+	for _, v := range []int{10, 20, 30} {
+		c.Assert((<-n.P("pout").Out()).Value(), Equals, v)
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestReset(c *C) {
+	n := cpn.NewPN()
+	for _, name := range []string{"pretry", "pcancel"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		)
+	}
+	n.T("cancel", cpn.WithTransformation(transition.First))
+	for _, name := range []string{"pout", "pdiscarded"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	var discarded int64
+	n.
+		PT("pcancel", "cancel").
+		PTReset("pretry", "cancel",
+			cpn.WithDiscard(func(m *cpn.M) {
+				atomic.AddInt64(&discarded, 1)
+			}),
+			cpn.WithDiscardPlace("pdiscarded"),
+		).
+		TP("cancel", "pout")
+	c.Assert(n.T("cancel").Resets(), DeepEquals, []string{"pretry"})
+	c.Assert(n.T("cancel").ResetDiscard("pretry"), Equals, "pdiscarded")
+	c.Assert(n.P("pdiscarded").Ins(), DeepEquals, []string{"cancel"})
+
+	w := bytes.NewBufferString("")
+	c.Assert(n.WriteDOT(w), IsNil)
+	c.Assert(w.String(), Matches, `(?s).*"p:pretry" -> "t:cancel" \[arrowhead=normalnormal\];
+	"t:cancel" -> "p:pdiscarded" \[style=dashed\];.*`)
+
+	c.Assert(n.Run(), IsNil)
+	for i := 0; i < 3; i += 1 {
+		n.P("pretry").Send(cpn.NewM(i))
+	}
+	// The place passes all retries before the cancellation
+	held(c, n, "pretry", 3)
+	n.P("pcancel").Send(cpn.NewM("cancel"))
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, "cancel")
+	for i := 0; i < 3; i += 1 {
+		m := <-n.P("pdiscarded").Out()
+		c.Assert(m.Value(), Equals, i)
+		c.Assert(m.Word(), DeepEquals, []string{"cancel"})
+	}
+	c.Assert(atomic.LoadInt64(&discarded), Equals, int64(3))
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestGuard(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("teven",
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			return mm[0].Value().(int)%2 == 0
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	n.T("todd",
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			return mm[0].Value().(int)%2 == 1
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	for _, name := range []string{"peven", "podd"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "teven").
+		PT("pin", "todd").
+		TP("teven", "peven").
+		TP("todd", "podd").
+		Run(), IsNil)
+
+	for i := 0; i < 10; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+	}
+	for i := 0; i < 10; i += 2 {
+		c.Assert((<-n.P("peven").Out()).Value(), Equals, i)
+		c.Assert((<-n.P("podd").Out()).Value(), Equals, i+1)
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestGuardSkipsRejected(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			return mm[0].Value().(int)%2 == 1
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	// Rejected token stays in the place, and the accepted token behind it is consumed
+	n.P("pin").Send(cpn.NewM(0))
+	n.P("pin").Send(cpn.NewM(1))
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := n.Shutdown(ctx)
+	c.Assert(errors.Is(err, cpn.ErrStrandedTokens), Equals, true)
+	c.Assert(err.(*cpn.ShutdownError).Stranded, DeepEquals, map[string]int{"pin": 1})
+}
+
+func (s *PNSuite) TestPriority(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("tmain",
+		cpn.WithPriority(1),
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			return mm[0].Value().(int) < 5
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	n.T("tfallback", cpn.WithTransformation(transition.First))
+	for _, name := range []string{"pmain", "pfallback"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "tmain").
+		PT("pin", "tfallback").
+		TP("tmain", "pmain").
+		TP("tfallback", "pfallback").
+		Run(), IsNil)
+	c.Assert(n.T("tmain").Priority(), Equals, 1)
+
+	for i := 0; i < 10; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+	}
+	for i := 0; i < 5; i += 1 {
+		c.Assert((<-n.P("pmain").Out()).Value(), Equals, i)
+		c.Assert((<-n.P("pfallback").Out()).Value(), Equals, i+5)
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestRouter(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithRouter(func(mm []*cpn.M) map[string]*cpn.M {
+		if mm[0].Value().(int) < 0 {
+			return map[string]*cpn.M{"perror": mm[0]}
+		}
+		return map[string]*cpn.M{"pok": mm[0]}
+	}))
+	for _, name := range []string{"pok", "perror"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pok").
+		TP("t1", "perror").
+		Run(), IsNil)
+
+	for _, v := range []int{1, -1, 2, -2} {
+		n.P("pin").Send(cpn.NewM(v))
+	}
+	for _, v := range []int{1, 2} {
+		m := <-n.P("pok").Out()
+		c.Assert(m.Value(), Equals, v)
+		c.Assert(m.Word(), DeepEquals, []string{"t1"})
+	}
+	for _, v := range []int{-1, -2} {
+		c.Assert((<-n.P("perror").Out()).Value(), Equals, v)
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestRouterDrops(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithRouter(func(mm []*cpn.M) map[string]*cpn.M {
+		if mm[0].Value().(int) < 0 {
+			return map[string]*cpn.M{"pok": nil}
+		}
+		return map[string]*cpn.M{"pok": mm[0]}
+	}))
+	n.P("pok",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pok").
+		Run(), IsNil)
+
+	for _, v := range []int{1, -1, 2} {
+		n.P("pin").Send(cpn.NewM(v))
+	}
+	for _, v := range []int{1, 2} {
+		c.Assert((<-n.P("pok").Out()).Value(), Equals, v)
+	}
+	err := n.Shutdown(context.Background())
+	c.Assert(errors.Is(err, cpn.ErrStrandedTokens), Equals, true)
+	c.Assert(err.(*cpn.ShutdownError).Dropped, DeepEquals, map[string]int{"t1": 1})
+}
+
+func (s *PNSuite) TestFallibleTransformation(c *C) {
+	var errNegative = errors.New("negative value")
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithFallibleTransformation(func(mm []*cpn.M) (*cpn.M, error) {
+		if mm[0].Value().(int) < 0 {
+			return nil, errNegative
+		}
+		return mm[0], nil
+	}))
+	for _, name := range []string{"pout", "perror"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPError("t1", "perror")
+	c.Assert(n.T("t1").Error(), Equals, "perror")
+
+	w := bytes.NewBufferString("")
+	c.Assert(n.WriteDOT(w), IsNil)
+	c.Assert(w.String(), Matches, `(?s).*"t:t1" -> "p:perror" \[color=red\];.*`)
+
+	c.Assert(n.Run(), IsNil)
+	for _, v := range []int{1, -1, 2} {
+		n.P("pin").Send(cpn.NewM(v))
+	}
+	for _, v := range []int{1, 2} {
+		m := <-n.P("pout").Out()
+		c.Assert(m.Value(), Equals, v)
+		c.Assert(m.Err(), IsNil)
+	}
+	m := <-n.P("perror").Out()
+	c.Assert(m.Value(), Equals, -1)
+	c.Assert(m.Err(), Equals, errNegative)
+	c.Assert(m.Word(), DeepEquals, []string{"t1"})
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestNoErrorPlace(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithFallibleTransformation(func(mm []*cpn.M) (*cpn.M, error) {
+		return mm[0], nil
+	}))
+	n.PT("pin", "t1")
+
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err.(*cpn.ValidationError).Errs[0], cpn.ErrNoErrorPlace), Equals, true)
+
+	n.SetErrorHandler(func(m *cpn.M, err error) {})
+	c.Assert(n.Validate(), IsNil)
+}
+
+func (s *PNSuite) TestTimeoutPlaceOnly(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithTimeout(time.Second),
+		cpn.WithFallibleTransformation(func(mm []*cpn.M) (*cpn.M, error) {
+			return mm[0], nil
+		}),
+	)
+	for _, name := range []string{"pout", "ptimeout", "perror"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPTimeout("t1", "ptimeout")
+
+	// Timeout place receives only timed out tokens, so other failures need the error place
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err.(*cpn.ValidationError).Errs[0], cpn.ErrNoErrorPlace), Equals, true)
+
+	n.TPError("t1", "perror")
+	c.Assert(n.Validate(), IsNil)
+}
+
+func (s *PNSuite) TestTransformationPanic(c *C) {
+	type failure struct {
+		m   *cpn.M
+		err error
+	}
+	var failures = make(chan failure, 1)
+	n := cpn.NewPN().SetErrorHandler(func(m *cpn.M, err error) {
+		failures <- failure{m, err}
+	})
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+		if mm[0].Value().(int) < 0 {
+			panic("negative value")
+		}
+		return mm[0]
+	}))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	for _, v := range []int{1, -1, 2} {
+		n.P("pin").Send(cpn.NewM(v))
+	}
+	for _, v := range []int{1, 2} {
+		c.Assert((<-n.P("pout").Out()).Value(), Equals, v)
+	}
+	f := <-failures
+	c.Assert(f.m.Value(), Equals, -1)
+	c.Assert(f.m.Err(), Equals, f.err)
+	c.Assert(f.err, ErrorMatches, "panic: negative value")
+	c.Assert(string(f.err.(*cpn.PanicError).Stack), Matches, `(?s).*pn_test.go.*`)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestTransformationPanicToErrorPlace(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+		panic(errors.New("broken"))
+	}))
+	for _, name := range []string{"pout", "perror"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPError("t1", "perror").
+		Run(), IsNil)
+
+	n.P("pin").Send(cpn.NewM(1))
+	m := <-n.P("perror").Out()
+	c.Assert(m.Value(), Equals, 1)
+	c.Assert(m.Err(), ErrorMatches, "panic: broken")
+	var pe *cpn.PanicError
+	c.Assert(errors.As(m.Err(), &pe), Equals, true)
+	c.Assert(errors.Unwrap(pe), ErrorMatches, "broken")
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestGuardPanic(c *C) {
+	var errs = make(chan error, 1)
+	n := cpn.NewPN()
+	// Handler locks places, so it deadlocks when it's called under places locks
+	n.SetErrorHandler(func(m *cpn.M, err error) {
+		c.Check(n.WriteDOT(bytes.NewBufferString(""), cpn.WithTokenCounts(true)), IsNil)
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1",
+		cpn.WithTransformation(transition.First),
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			if mm[0].Value().(int) < 0 {
+				panic("negative value")
+			}
+			return true
+		}),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	n.P("pin").Send(cpn.NewM(-1))
+	c.Assert(<-errs, ErrorMatches, "panic: negative value")
+	n.P("pin").Send(cpn.NewM(1))
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+
+	err := n.Shutdown(context.Background())
+	c.Assert(errors.Is(err, cpn.ErrStrandedTokens), Equals, true)
+	c.Assert(err.(*cpn.ShutdownError).Stranded, DeepEquals, map[string]int{"pin": 1})
+}
+
+func (s *PNSuite) TestConcurrency(c *C) {
+	var running, max int64
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1",
+		cpn.WithConcurrency(4),
+		cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+			r := atomic.AddInt64(&running, 1)
+			for m := atomic.LoadInt64(&max); r > m && !atomic.CompareAndSwapInt64(&max, m, r); {
+				m = atomic.LoadInt64(&max)
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt64(&running, -1)
+			return mm[0]
+		}),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+	c.Assert(n.T("t1").Concurrency(), Equals, 4)
+
+	for i := 0; i < 8; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+	}
+	var seen = map[int]bool{}
+	for i := 0; i < 8; i += 1 {
+		seen[(<-n.P("pout").Out()).Value().(int)] = true
+	}
+	c.Assert(seen, HasLen, 8)
+	c.Assert(atomic.LoadInt64(&max) > 1, Equals, true)
+	c.Assert(atomic.LoadInt64(&max) <= 4, Equals, true)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestConcurrencyBusy(c *C) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1",
+		cpn.WithConcurrency(2),
+		cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+			started <- struct{}{}
+			<-release
+			return mm[0]
+		}),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	for i := 0; i < 2; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+		<-started
+	}
+	// Both slots are busy, so the third token stays in the place instead of being consumed
+	n.P("pin").Send(cpn.NewM(2))
+	for {
+		w := bytes.NewBufferString("")
+		c.Assert(n.WriteDOT(w, cpn.WithTokenCounts(true)), IsNil)
+		c.Assert(w.String(), Not(Matches), `(?s).*"p:pin" -> "t:t1" \[label="3"\].*`)
+		if strings.Contains(w.String(), `label="pin\n1"`) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	<-started
+	for i := 0; i < 3; i += 1 {
+		<-n.P("pout").Out()
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestConcurrencyOrdered(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+	)
+	n.T("t1",
+		cpn.WithConcurrency(4),
+		cpn.WithOrdered(true),
+		cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+			// Earlier tokens are processed longer
+			time.Sleep(time.Duration(10-mm[0].Value().(int)) * 2 * time.Millisecond)
+			return mm[0]
+		}),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	for i := 0; i < 10; i += 1 {
+		n.P("pin").Send(cpn.NewM(i))
+	}
+	for i := 0; i < 10; i += 1 {
+		c.Assert((<-n.P("pout").Out()).Value(), Equals, i)
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestInvalidConcurrency(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithConcurrency(0), cpn.WithTransformation(transition.First))
+	n.PT("pin", "t1")
+
+	err := n.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(errors.Is(err.(*cpn.ValidationError).Errs[0], cpn.ErrInvalidConcurrency), Equals, true)
+}
+
+func (s *PNSuite) TestDelay(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithDelay(50*time.Millisecond), cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	n.P("pin").Send(cpn.NewM(1))
+	m := <-n.P("pout").Out()
+	// Path is pin, t1, pout. Transition fires not earlier than the delay after the token is passed by the place
+	c.Assert(m.Path()[1].T.Sub(m.Path()[0].T) >= 50*time.Millisecond, Equals, true)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestTimeout(c *C) {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithTimeout(20*time.Millisecond),
+		cpn.WithContextTransformation(func(ctx context.Context, mm []*cpn.M) (*cpn.M, error) {
+			if mm[0].Value().(int) > 0 {
+				return mm[0], nil
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+	)
+	for _, name := range []string{"pout", "perror", "ptimeout"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPError("t1", "perror").
+		TPTimeout("t1", "ptimeout")
+	c.Assert(n.T("t1").Timeout(), Equals, "ptimeout")
+	c.Assert(n.Run(), IsNil)
+
+	n.P("pin").Send(cpn.NewM(0))
+	n.P("pin").Send(cpn.NewM(1))
+	m := <-n.P("ptimeout").Out()
+	c.Assert(m.Value(), Equals, 0)
+	c.Assert(m.Err(), Equals, cpn.ErrFiringTimeout)
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestClockDelay(c *C) {
+	var (
+		start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock = cpntest.NewClock(start)
+		n     = cpn.NewPN().SetClock(clock)
+	)
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1", cpn.WithDelay(time.Hour), cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(strategies.NewPass(strategies.PassFuncOption(
+			func(ctx context.Context, m *cpn.M) *cpn.M {
+				m.SetValue(cpn.ClockFromContext(ctx).Now())
+				return m
+			},
+		))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	n.P("pin").Send(n.NewM(1))
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	m := <-n.P("pout").Out()
+	c.Assert(m.Value(), Equals, start.Add(time.Hour))
+	var tt []time.Time
+	for _, e := range m.History() {
+		tt = append(tt, e.T)
+	}
+	// History is the creation, pin, t1 and pout
+	c.Assert(tt, DeepEquals, []time.Time{start, start, start.Add(time.Hour), start.Add(time.Hour)})
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestClockTimeout(c *C) {
+	var (
+		clock = cpntest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		n     = cpn.NewPN().SetClock(clock)
+	)
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithTimeout(time.Minute),
+		cpn.WithContextTransformation(func(ctx context.Context, mm []*cpn.M) (*cpn.M, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+	)
+	for _, name := range []string{"pout", "perror", "ptimeout"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPError("t1", "perror").
+		TPTimeout("t1", "ptimeout").
+		Run(), IsNil)
+
+	n.P("pin").Send(n.NewM(0))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	m := <-n.P("ptimeout").Out()
+	c.Assert(m.Err(), Equals, cpn.ErrFiringTimeout)
+	c.Assert(m.Path()[1].T.Sub(m.Path()[0].T), Equals, time.Minute)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *PNSuite) TestAbandonedCall(c *C) {
+	var (
+		clock    = cpntest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		n        = cpn.NewPN().SetClock(clock)
+		release  = make(chan struct{})
+		finished = make(chan struct{})
+		calls    int64
+	)
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("t1",
+		cpn.WithTimeout(time.Minute),
+		cpn.WithTransformation(func(mm []*cpn.M) *cpn.M {
+			atomic.AddInt64(&calls, 1)
+			<-release
+			mm[0].SetValue("late")
+			close(finished)
+			return mm[0]
+		}),
+	)
+	for _, name := range []string{"pout", "ptimeout"} {
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+			cpn.WithKeep(true),
+		)
+	}
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		TPTimeout("t1", "ptimeout").
+		Run(), IsNil)
+
+	n.P("pin").Send(n.NewM(1))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	m1 := <-n.P("ptimeout").Out()
+	c.Assert(m1.Err(), Equals, cpn.ErrFiringTimeout)
+
+	// Abandoned call holds the only slot, so the next firing times out without a call
+	n.P("pin").Send(n.NewM(2))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	m2 := <-n.P("ptimeout").Out()
+	c.Assert(m2.Err(), Equals, cpn.ErrFiringTimeout)
+	c.Assert(atomic.LoadInt64(&calls), Equals, int64(1))
+
+	// Abandoned call changes its own copy of the token
+	close(release)
+	<-finished
+	c.Assert(m1.Value(), Equals, 1)
+	c.Assert(m2.Value(), Equals, 2)
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/place/memory"
+	"github.com/alxmsl/cpn/pnml"
+	"github.com/alxmsl/cpn/transition"
+)
+
+type PNMLSuite struct{}
+
+var _ = Suite(&PNMLSuite{})
+
+const pnmlDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<pnml xmlns="http://www.pnml.org/version-2009/grammar/pnml">
+  <net id="n" type="http://www.pnml.org/version-2009/grammar/ptnet">
+    <page id="pg">
+      <place id="p1">
+        <name><text>pin</text></name>
+        <initialMarking><text>2</text></initialMarking>
+        <toolspecific tool="cpn" version="1.0"><strategy>queue</strategy></toolspecific>
+      </place>
+      <transition id="t1">
+        <name><text>t1</text></name>
+      </transition>
+      <page id="nested">
+        <place id="p2"><name><text>pout</text></name></place>
+      </page>
+      <arc id="a1" source="p1" target="t1"/>
+      <arc id="a2" source="t1" target="p2"/>
+    </page>
+  </net>
+</pnml>`
+
+func (s *PNMLSuite) TestDecode(c *C) {
+	reg := cpn.NewRegistry().
+		AddStrategy("queue", memory.NewQueue, memory.LengthOption(2)).
+		AddStrategy("pout", memory.NewBlock).
+		AddTransformation("t1", transition.First)
+
+	doc, err := pnml.Decode(strings.NewReader(pnmlDocument))
+	c.Assert(err, IsNil)
+	marking, err := doc.Marking()
+	c.Assert(err, IsNil)
+	c.Assert(marking, DeepEquals, map[string]int{"pin": 2})
+
+	n, err := doc.Build(reg, cpn.WithContext(context.Background()))
+	c.Assert(err, IsNil)
+	c.Assert(n.P("pout").Terminal(), Equals, true)
+	c.Assert(n.P("pin").Initial(), Equals, true)
+	c.Assert(n.T("t1").Ins(), DeepEquals, []string{"pin"})
+	c.Assert(n.T("t1").Outs(), DeepEquals, []string{"pout"})
+	n.P("pout").SetOptions(cpn.WithKeep(true))
+	c.Assert(n.Run(), IsNil)
+
+	n.P("pin").In() <- cpn.NewM(1)
+	m := <-n.P("pout").Out()
+	c.Assert(m.Word(), DeepEquals, []string{"t1"})
+}
+
+func (s *PNMLSuite) TestDecodeUnknownStrategy(c *C) {
+	doc, err := pnml.Decode(strings.NewReader(pnmlDocument))
+	c.Assert(err, IsNil)
+	_, err = doc.Build(cpn.NewRegistry())
+	c.Assert(err, ErrorMatches, `pnml: place "pin": unknown strategy "queue"`)
+}
+
+func (s *PNMLSuite) TestDecodeInvalidDelay(c *C) {
+	doc, err := pnml.Decode(strings.NewReader(strings.Replace(pnmlDocument, `<name><text>t1</text></name>`,
+		`<name><text>t1</text></name><toolspecific tool="cpn" version="1.0"><delay>1x</delay></toolspecific>`, 1)))
+	c.Assert(err, IsNil)
+	_, err = doc.Build(cpn.NewRegistry().AddStrategy("queue", memory.NewQueue))
+	c.Assert(err, ErrorMatches, `pnml: transition "t1": delay: time: unknown unit "?x"? in duration "?1x"?`)
+}
+
+func (s *PNMLSuite) TestEncode(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("pin", "t1").
+		TP("t1", "pout")
+
+	w := bytes.NewBufferString("")
+	c.Assert(pnml.Encode(w, n), IsNil)
+	c.Assert(w.String(), Equals, `<?xml version="1.0" encoding="UTF-8"?>
+<pnml xmlns="http://www.pnml.org/version-2009/grammar/pnml">
+  <net id="net" type="http://www.pnml.org/version-2009/grammar/ptnet">
+    <page id="page">
+      <place id="p0">
+        <name>
+          <text>pin</text>
+        </name>
+      </place>
+      <place id="p1">
+        <name>
+          <text>pout</text>
+        </name>
+      </place>
+      <transition id="t0">
+        <name>
+          <text>t1</text>
+        </name>
+      </transition>
+      <arc id="a0" source="p0" target="t0"></arc>
+      <arc id="a1" source="t0" target="p1"></arc>
+    </page>
+  </net>
+</pnml>
+`)
+
+	doc, err := pnml.Decode(w)
+	c.Assert(err, IsNil)
+	n, err = doc.Build(cpn.NewRegistry())
+	c.Assert(err, IsNil)
+	c.Assert(n.T("t1").Ins(), DeepEquals, []string{"pin"})
+	c.Assert(n.T("t1").Outs(), DeepEquals, []string{"pout"})
+}
+
+func (s *PNMLSuite) TestWeights(c *C) {
+	n := cpn.NewPN()
+	n.
+		PT("pin", "t1", cpn.WithWeight(3)).
+		TP("t1", "pout", cpn.WithWeight(2))
+
+	w := bytes.NewBufferString("")
+	c.Assert(pnml.Encode(w, n), IsNil)
+	c.Assert(strings.Count(w.String(), "<inscription>"), Equals, 2)
+
+	doc, err := pnml.Decode(w)
+	c.Assert(err, IsNil)
+	n, err = doc.Build(cpn.NewRegistry())
+	c.Assert(err, IsNil)
+	c.Assert(n.T("t1").InWeight("pin"), Equals, 3)
+	c.Assert(n.T("t1").OutWeight("pout"), Equals, 2)
+}
+
+func (s *PNMLSuite) TestArcTypes(c *C) {
+	n := cpn.NewPN()
+	n.T("t1",
+		cpn.WithPriority(2),
+		cpn.WithConcurrency(4),
+		cpn.WithOrdered(true),
+		cpn.WithDelay(time.Second),
+		cpn.WithTimeout(1500*time.Millisecond),
+	)
+	n.
+		PT("pin", "t1").
+		PTRead("pflag", "t1").
+		PTReset("pretry", "t1", cpn.WithDiscardPlace("pdiscarded")).
+		PTInhibitor("pstop", "t1", cpn.WithWeight(2)).
+		TP("t1", "pout").
+		TPError("t1", "perror").
+		TPTimeout("t1", "ptimeout")
+
+	w := bytes.NewBufferString("")
+	c.Assert(pnml.Encode(w, n), IsNil)
+	c.Assert(strings.Count(w.String(), `<type value="read"></type>`), Equals, 1)
+	c.Assert(strings.Count(w.String(), `<type value="reset"></type>`), Equals, 1)
+	c.Assert(strings.Count(w.String(), `<type value="inhibitor"></type>`), Equals, 1)
+	c.Assert(strings.Count(w.String(), `<type value="error"></type>`), Equals, 1)
+	c.Assert(strings.Count(w.String(), `<type value="timeout"></type>`), Equals, 1)
+	c.Assert(strings.Count(w.String(), `<timeout>1.5s</timeout>`), Equals, 1)
+
+	doc, err := pnml.Decode(w)
+	c.Assert(err, IsNil)
+	n, err = doc.Build(cpn.NewRegistry())
+	c.Assert(err, IsNil)
+	c.Assert(n.T("t1").Priority(), Equals, 2)
+	c.Assert(n.T("t1").Concurrency(), Equals, 4)
+	c.Assert(n.T("t1").Ordered(), Equals, true)
+	c.Assert(n.T("t1").Delay(), Equals, time.Second)
+	c.Assert(n.T("t1").FiringTimeout(), Equals, 1500*time.Millisecond)
+	c.Assert(n.T("t1").Ins(), DeepEquals, []string{"pin"})
+	c.Assert(n.T("t1").Reads(), DeepEquals, []string{"pflag"})
+	c.Assert(n.T("t1").Resets(), DeepEquals, []string{"pretry"})
+	c.Assert(n.T("t1").ResetDiscard("pretry"), Equals, "pdiscarded")
+	c.Assert(n.T("t1").Inhibitors(), DeepEquals, []string{"pstop"})
+	c.Assert(n.T("t1").InhibitorWeight("pstop"), Equals, 2)
+	c.Assert(n.T("t1").Outs(), DeepEquals, []string{"pout"})
+	c.Assert(n.T("t1").Error(), Equals, "perror")
+	c.Assert(n.T("t1").Timeout(), Equals, "ptimeout")
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/place/memory"
+	"github.com/alxmsl/cpn/transition"
+)
+
+type SpecSuite struct{}
+
+var _ = Suite(&SpecSuite{})
+
+func registry() *cpn.Registry {
+	var r = cpn.NewRegistry()
+	memory.Register(r)
+	transition.Register(r)
+	return r
+}
+
+func (s *SpecSuite) TestLoadJSON(c *C) {
+	spec, err := cpn.DecodeJSON(strings.NewReader(`{
+		"places": [
+			{"name": "pin", "strategy": "memory.queue", "options": {"length": 10}},
+			{"name": "pout", "strategy": "memory.block", "keep": true}
+		],
+		"transitions": [
+			{"name": "t1", "transformation": "first"}
+		],
+		"arcs": [
+			{"from": "pin", "to": "t1"},
+			{"from": "t1", "to": "pout"}
+		]
+	}`))
+	c.Assert(err, IsNil)
+	n, err := cpn.Load(spec, registry())
+	c.Assert(err, IsNil)
+	c.Assert(n.Run(), IsNil)
+
+	for i := 0; i < 10; i += 1 {
+		n.P("pin").In() <- cpn.NewM(i)
+	}
+	for i := 0; i < 10; i += 1 {
+		m := <-n.P("pout").Out()
+		c.Assert(m.Value(), Equals, i)
+		c.Assert(m.Word(), DeepEquals, []string{"t1"})
+	}
+}
+
+func (s *SpecSuite) TestLoadYAML(c *C) {
+	spec, err := cpn.DecodeYAML(strings.NewReader(`
+places:
+  - name: pin
+    strategy: memory.queue
+    options:
+      length: 10
+  - name: pout
+    strategy: memory.block
+    keep: true
+transitions:
+  - name: t1
+    transformation: first
+arcs:
+  - from: pin
+    to: t1
+    weight: 2
+  - from: t1
+    to: pout
+`))
+	c.Assert(err, IsNil)
+	n, err := cpn.Load(spec, registry())
+	c.Assert(err, IsNil)
+	c.Assert(n.T("t1").Ins(), DeepEquals, []string{"pin"})
+	c.Assert(n.T("t1").Outs(), DeepEquals, []string{"pout"})
+	c.Assert(n.T("t1").InWeight("pin"), Equals, 2)
+	c.Assert(n.T("t1").OutWeight("pout"), Equals, 1)
+	c.Assert(n.Validate(), IsNil)
+}
+
+func (s *SpecSuite) TestDecodeUnknownField(c *C) {
+	_, err := cpn.DecodeYAML(strings.NewReader(`
+places:
+  - name: pin
+    strategy: memory.queue
+transitions:
+  - name: t1
+    transformaton: first
+`))
+	c.Assert(err, ErrorMatches, `spec: json: unknown field "transformaton"`)
+
+	_, err = cpn.DecodeJSON(strings.NewReader(`{"places": [{"name": "pin", "stratgy": "memory.queue"}]}`))
+	c.Assert(err, ErrorMatches, `spec: json: unknown field "stratgy"`)
+}
+
+func (s *SpecSuite) TestLoadErrors(c *C) {
+	_, err := cpn.Load(&cpn.Spec{
+		Places: []cpn.PlaceSpec{{Name: "pin", Strategy: "memory.queue", Options: map[string]json.RawMessage{
+			"size": json.RawMessage("1"),
+		}}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: place "pin": strategy "memory.queue": unknown option "size"`)
+
+	_, err = cpn.Load(&cpn.Spec{
+		Places: []cpn.PlaceSpec{{Name: "pin", Strategy: "memory.lifo"}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: place "pin": unknown strategy "memory.lifo"`)
+
+	_, err = cpn.Load(&cpn.Spec{
+		Places: []cpn.PlaceSpec{
+			{Name: "p1", Strategy: "memory.block"},
+			{Name: "p2", Strategy: "memory.block"},
+		},
+		Arcs: []cpn.ArcSpec{{From: "p1", To: "p2"}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: arc "p1" -> "p2": must connect a place and a transition`)
+
+	_, err = cpn.Load(&cpn.Spec{
+		Places:      []cpn.PlaceSpec{{Name: "p1", Strategy: "memory.block"}},
+		Transitions: []cpn.TransitionSpec{{Name: "t1", Transformation: "first"}},
+		Arcs:        []cpn.ArcSpec{{From: "t1", To: "p1", Kind: cpn.ArcInhibitor}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: arc "t1" -> "p1": inhibitor must go from a place to a transition`)
+
+	_, err = cpn.Load(&cpn.Spec{
+		Places:      []cpn.PlaceSpec{{Name: "p1", Strategy: "memory.block"}},
+		Transitions: []cpn.TransitionSpec{{Name: "t1", Transformation: "first"}},
+		Arcs:        []cpn.ArcSpec{{From: "p1", To: "t1", Kind: cpn.ArcReset, Discard: "p2"}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: arc "p1" -> "t1": unknown discard place "p2"`)
+
+	_, err = cpn.Load(&cpn.Spec{
+		Places:      []cpn.PlaceSpec{{Name: "p1", Strategy: "memory.block"}},
+		Transitions: []cpn.TransitionSpec{{Name: "t1", Transformation: "first", Delay: "1 second"}},
+	}, registry())
+	c.Assert(err, ErrorMatches, `spec: transition "t1": delay: time: .*`)
+}
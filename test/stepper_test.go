@@ -0,0 +1,222 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/cpntest"
+	"github.com/alxmsl/cpn/place/memory"
+	"github.com/alxmsl/cpn/strategies"
+	"github.com/alxmsl/cpn/transition"
+)
+
+type StepperSuite struct{}
+
+var _ = Suite(&StepperSuite{})
+
+func conflict() *cpn.PN {
+	n := cpn.NewPN()
+	for _, p := range []string{"pin", "p1", "p2"} {
+		n.P(p, cpn.WithContext(context.Background()), cpn.WithStrategy(memory.NewBlock()))
+	}
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.T("t2", cpn.WithTransformation(transition.First))
+	return n.
+		PT("pin", "t1").
+		TP("t1", "p1").
+		PT("pin", "t2").
+		TP("t2", "p2")
+}
+
+func (s *StepperSuite) TestScriptPolicy(c *C) {
+	st, err := cpn.NewStepper(conflict(), cpn.WithPolicy(cpn.ScriptPolicy("t2", "t1", "t2", "t3")))
+	c.Assert(err, IsNil)
+	c.Assert(st.Enabled(), HasLen, 0)
+	for i := 0; i < 4; i += 1 {
+		c.Assert(st.Put("pin", cpn.NewM(i)), IsNil)
+	}
+	c.Assert(st.Enabled(), DeepEquals, []string{"t1", "t2"})
+	c.Assert(st.Marking()["pin"], HasLen, 4)
+
+	for _, t := range []string{"t2", "t1", "t2"} {
+		n, err := st.Step()
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, t)
+	}
+	_, err = st.Step()
+	c.Assert(errors.Is(err, cpn.ErrNotEnabled), Equals, true)
+	_, err = st.Step()
+	c.Assert(err, Equals, cpn.ErrScriptOver)
+
+	mm := st.Marking()
+	c.Assert(mm["pin"], HasLen, 1)
+	c.Assert(mm["p1"], HasLen, 1)
+	c.Assert(mm["p1"][0].Value(), Equals, 1)
+	c.Assert(mm["p2"], HasLen, 2)
+	c.Assert(mm["p2"][0].Value(), Equals, 0)
+	c.Assert(mm["p2"][0].Word(), DeepEquals, []string{"t2"})
+	c.Assert(mm["p2"][1].Value(), Equals, 2)
+}
+
+func (s *StepperSuite) TestFirstPolicy(c *C) {
+	st, err := cpn.NewStepper(conflict())
+	c.Assert(err, IsNil)
+	c.Assert(st.Put("pin", cpn.NewM(1)), IsNil)
+	c.Assert(st.Put("pin", cpn.NewM(2)), IsNil)
+	for i := 0; i < 2; i += 1 {
+		n, err := st.Step()
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, "t1")
+	}
+	_, err = st.Step()
+	c.Assert(err, Equals, cpn.ErrNoEnabledTransitions)
+	c.Assert(st.Marking()["p1"], HasLen, 2)
+}
+
+func (s *StepperSuite) TestRandomPolicy(c *C) {
+	var run = func(seed int64) []string {
+		st, err := cpn.NewStepper(conflict(), cpn.WithPolicy(cpn.RandomPolicy(seed)))
+		c.Assert(err, IsNil)
+		for i := 0; i < 20; i += 1 {
+			c.Assert(st.Put("pin", cpn.NewM(i)), IsNil)
+		}
+		var tt []string
+		for {
+			n, err := st.Step()
+			if err == cpn.ErrNoEnabledTransitions {
+				return tt
+			}
+			c.Assert(err, IsNil)
+			tt = append(tt, n)
+		}
+	}
+	var tt = run(5)
+	c.Assert(tt, HasLen, 20)
+	c.Assert(run(5), DeepEquals, tt)
+}
+
+// passJoin creates a net which multiplies tokens by ten and sums them. Sum is passed only when the place is closed
+func passJoin() *cpn.PN {
+	n := cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(strategies.NewPass(strategies.PassFuncOption(
+			func(ctx context.Context, m *cpn.M) *cpn.M {
+				m.SetValue(m.Value().(int) * 10)
+				return m
+			},
+		))),
+	)
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(strategies.NewJoin(strategies.JoinFuncOption(
+			func(ctx context.Context, ch <-chan *cpn.M) *cpn.M {
+				var sum int
+				for m := range ch {
+					sum += m.Value().(int)
+				}
+				return cpn.NewM(sum)
+			},
+		))),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	return n.PT("pin", "t1").TP("t1", "pout")
+}
+
+func (s *StepperSuite) TestStrategies(c *C) {
+	st, err := cpn.NewStepper(passJoin(), cpn.WithSettle(time.Millisecond))
+	c.Assert(err, IsNil)
+	for i := 1; i <= 3; i += 1 {
+		c.Assert(st.Put("pin", cpn.NewM(i)), IsNil)
+	}
+	c.Assert(st.Marking()["pin"], HasLen, 3)
+	for i := 0; i < 3; i += 1 {
+		_, err := st.Step()
+		c.Assert(err, IsNil)
+	}
+	c.Assert(st.Marking()["pout"], HasLen, 0)
+
+	st.Close("pout")
+	mm := st.Marking()
+	c.Assert(mm["pout"], HasLen, 1)
+	c.Assert(mm["pout"][0].Value(), Equals, 60)
+	c.Assert(errors.Is(st.Put("pout", cpn.NewM(0)), cpn.ErrPlaceClosed), Equals, true)
+}
+
+func (s *StepperSuite) TestClockSettle(c *C) {
+	var (
+		clock = cpntest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		n     = passJoin().SetClock(clock)
+	)
+	st, err := cpn.NewStepper(n, cpn.WithSettle(time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(st.Put("pin", n.NewM(1)), IsNil)
+	// Join strategy doesn't pass the token, so the step waits for the settle duration by the wall clock, while the
+	// manual clock stays still
+	_, err = st.Step()
+	c.Assert(err, IsNil)
+	c.Assert(st.Marking()["pout"], HasLen, 0)
+}
+
+func (s *StepperSuite) TestDelay(c *C) {
+	var (
+		clock = cpntest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		n     = cpn.NewPN().SetClock(clock)
+	)
+	for _, p := range []string{"pin", "pout"} {
+		n.P(p, cpn.WithContext(context.Background()), cpn.WithStrategy(memory.NewBlock()))
+	}
+	n.T("t1", cpn.WithTransformation(transition.First), cpn.WithDelay(time.Minute))
+	st, err := cpn.NewStepper(n.PT("pin", "t1").TP("t1", "pout"))
+	c.Assert(err, IsNil)
+
+	c.Assert(st.Put("pin", n.NewM(1)), IsNil)
+	_, err = st.Step()
+	c.Assert(err, Equals, cpn.ErrNoEnabledTransitions)
+	clock.Advance(time.Second * 59)
+	c.Assert(st.Enabled(), HasLen, 0)
+	clock.Advance(time.Second)
+	c.Assert(st.Enabled(), DeepEquals, []string{"t1"})
+	name, err := st.Step()
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "t1")
+
+	// Delay is counted again for the next token
+	c.Assert(st.Put("pin", n.NewM(2)), IsNil)
+	c.Assert(st.Enabled(), HasLen, 0)
+	clock.Advance(time.Minute)
+	c.Assert(st.Enabled(), DeepEquals, []string{"t1"})
+}
+
+func (s *StepperSuite) TestGuardBinding(c *C) {
+	n := cpn.NewPN()
+	for _, p := range []string{"pin", "pout"} {
+		n.P(p, cpn.WithContext(context.Background()), cpn.WithStrategy(memory.NewBlock()))
+	}
+	n.T("t1",
+		cpn.WithGuard(func(mm []*cpn.M) bool {
+			return mm[0].Value().(int)+mm[1].Value().(int) == 5
+		}),
+		cpn.WithTransformation(transition.First),
+	)
+	n.PT("pin", "t1", cpn.WithWeight(2)).TP("t1", "pout")
+
+	st, err := cpn.NewStepper(n)
+	c.Assert(err, IsNil)
+	for i := 1; i <= 3; i += 1 {
+		c.Assert(st.Put("pin", cpn.NewM(i)), IsNil)
+	}
+	_, err = st.Step()
+	c.Assert(err, IsNil)
+	mm := st.Marking()
+	c.Assert(mm["pin"], HasLen, 1)
+	c.Assert(mm["pin"][0].Value(), Equals, 1)
+	c.Assert(mm["pout"], HasLen, 1)
+	c.Assert(mm["pout"][0].Value(), Equals, 2)
+	_, err = st.Step()
+	c.Assert(err, Equals, cpn.ErrNoEnabledTransitions)
+}
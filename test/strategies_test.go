@@ -2,10 +2,17 @@ package test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	. "gopkg.in/check.v1"
 
 	"github.com/alxmsl/cpn"
+	"github.com/alxmsl/cpn/place"
+	phttp "github.com/alxmsl/cpn/place/http"
+	"github.com/alxmsl/cpn/place/memory"
 	"github.com/alxmsl/cpn/strategies"
 	"github.com/alxmsl/cpn/transition"
 )
@@ -72,7 +79,7 @@ func (s *StrategiesSuite) TestForkStrategy(c *C) {
 		cpn.WithKeep(true),
 	)
 
-	n.
+	c.Assert(n.
 		PT("pin", "t1.1").
 		TP("t1.1", "p1").
 		PT("p1", "t1.2").
@@ -81,7 +88,7 @@ func (s *StrategiesSuite) TestForkStrategy(c *C) {
 		TP("t2.1", "p2").
 		PT("p2", "t2.2").
 		TP("t2.2", "pout2").
-		Run()
+		Run(), IsNil)
 
 	// Writes several token to the PN concurrently. We use bigger number to be sure tokens are passed through several
 	// branches. With small number tokens may be passed through one branch
@@ -156,12 +163,12 @@ func (s *StrategiesSuite) TestJoinStrategy(c *C) {
 		cpn.WithKeep(true),
 	)
 
-	n.
+	c.Assert(n.
 		PT("pin1", "t1.1").
 		PT("pin2", "t2.1").
 		TP("t1.1", "pout").
 		TP("t2.1", "pout").
-		Run()
+		Run(), IsNil)
 
 	// Writes several token to the PN concurrently
 	n.P("pin1").Send(cpn.NewM("initial value 1"))
@@ -208,12 +215,12 @@ func (s *StrategiesSuite) TestPassStrategy(c *C) {
 		))),
 		cpn.WithKeep(true),
 	)
-	n.
+	c.Assert(n.
 		PT("pin", "t1").
 		TP("t1", "p").
 		PT("p", "t2").
 		TP("t2", "pout").
-		Run()
+		Run(), IsNil)
 
 	n.P("pin").In() <- cpn.NewM("initial value")
 	var m = <-n.P("pout").Out()
@@ -221,3 +228,117 @@ func (s *StrategiesSuite) TestPassStrategy(c *C) {
 	c.Assert(m.Word()[0], Equals, "t1")
 	c.Assert(m.Word()[1], Equals, "t2")
 }
+
+func (s *StrategiesSuite) TestForkTransition(c *C) {
+	var n = cpn.NewPN()
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+	)
+	n.T("tfork", cpn.WithTransformation(transition.First))
+	for _, name := range []string{"p1", "p2"} {
+		value := "value from " + name
+		n.P(name,
+			cpn.WithContext(context.Background()),
+			cpn.WithStrategy(strategies.NewPass(strategies.PassFuncOption(
+				func(ctx context.Context, m *cpn.M) *cpn.M {
+					m.SetValue(value)
+					return m
+				},
+			))),
+		)
+	}
+	n.T("tjoin", cpn.WithTransformation(transition.Join))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewBlock()),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "tfork").
+		TP("tfork", "p1").
+		TP("tfork", "p2").
+		PT("p1", "tjoin").
+		PT("p2", "tjoin").
+		TP("tjoin", "pout").
+		Run(), IsNil)
+
+	const loops = 10
+	for i := 0; i < loops; i += 1 {
+		m := cpn.NewM(i)
+		n.P("pin").Send(m)
+
+		j := <-n.P("pout").Out()
+		c.Assert(j.Parent(), Equals, m.ID())
+		c.Assert(j.Branches(), HasLen, 2)
+		c.Assert(j.Branches()[0].Parent(), Equals, m.ID())
+		c.Assert(j.Branches()[1].Parent(), Equals, m.ID())
+		c.Assert(j.Branches()[0].ID(), Not(Equals), j.Branches()[1].ID())
+		c.Assert(j.ValueByPlace("p1", 0), Equals, "value from p1")
+		c.Assert(j.ValueByPlace("p2", 0), Equals, "value from p2")
+		c.Assert(j.ValueByPlace("pin", 0), Equals, i)
+		c.Assert(j.Word(), DeepEquals, []string{"tfork", "tjoin"})
+
+		var names []string
+		for _, e := range j.History()[1:] {
+			names = append(names, e.N)
+		}
+		c.Assert(names[:2], DeepEquals, []string{"pin", "tfork"})
+		// Branches are passed concurrently, so their edges are ordered by time
+		sort.Strings(names[2:4])
+		c.Assert(names[2:4], DeepEquals, []string{"p1", "p2"})
+		c.Assert(names[4:], DeepEquals, []string{"tjoin", "pout"})
+	}
+	c.Assert(n.Shutdown(context.Background()), IsNil)
+}
+
+func (s *StrategiesSuite) TestPassStrategyPanic(c *C) {
+	var (
+		errs = make(chan error, 1)
+		n    = cpn.NewPN()
+	)
+	n.P("pin",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(strategies.NewPass(
+			strategies.PassFuncOption(func(ctx context.Context, m *cpn.M) *cpn.M {
+				if m.Value().(int) < 0 {
+					panic("negative value")
+				}
+				return m
+			}),
+			place.ErrorsOutOption(errs),
+		)),
+	)
+	n.T("t1", cpn.WithTransformation(transition.First))
+	n.P("pout",
+		cpn.WithContext(context.Background()),
+		cpn.WithStrategy(memory.NewQueue(memory.LengthOption(10))),
+		cpn.WithKeep(true),
+	)
+	c.Assert(n.
+		PT("pin", "t1").
+		TP("t1", "pout").
+		Run(), IsNil)
+
+	n.P("pin").Send(cpn.NewM(-1))
+	err := <-errs
+	c.Assert(err, ErrorMatches, "panic: negative value")
+	c.Assert(err.(*cpn.PanicError).M.Value(), Equals, -1)
+	c.Assert(err.(*cpn.PanicError).M.Err(), Equals, err)
+
+	n.P("pin").Send(cpn.NewM(1))
+	c.Assert((<-n.P("pout").Out()).Value(), Equals, 1)
+
+	err = n.Shutdown(context.Background())
+	c.Assert(errors.Is(err, cpn.ErrStrandedTokens), Equals, true)
+	c.Assert(err.(*cpn.ShutdownError).Dropped, DeepEquals, map[string]int{"pin": 1})
+}
+
+func (s *StrategiesSuite) TestRequestServerFailure(c *C) {
+	// Pattern is unique, because handlers are registered in the default mux once per process
+	pattern := fmt.Sprintf("/failure/%d", time.Now().UnixNano())
+	st := phttp.NewRequest(phttp.AddressOption("localhost:-1"), phttp.PatternOption(pattern))
+	go st.Run(context.Background())
+	_, ok := <-st.Out()
+	c.Assert(ok, Equals, false)
+}
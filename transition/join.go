@@ -0,0 +1,8 @@
+package transition
+
+import "github.com/alxmsl/cpn"
+
+// Join reunites tokens of branches into one token. See cpn.Join for details
+func Join(mm []*cpn.M) *cpn.M {
+	return cpn.Join(mm...)
+}
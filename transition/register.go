@@ -0,0 +1,9 @@
+package transition
+
+import "github.com/alxmsl/cpn"
+
+// Register registers transformations in the registry. First is registered as `first`, Join is registered as `join`
+func Register(r *cpn.Registry) {
+	r.AddTransformation("first", First)
+	r.AddTransformation("join", Join)
+}